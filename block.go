@@ -3,6 +3,7 @@ package notionapi
 import (
 	"fmt"
 	"sort"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -43,8 +44,10 @@ const (
 	BlockColumnList = "column_list"
 	// BlockColumn is a child of TypeColumnList
 	BlockColumn = "column"
-	// BlockTable is a table block
+	// BlockTable is a table block. Its rows are BlockTableRow children
 	BlockTable = "table"
+	// BlockTableRow is a row of BlockTable
+	BlockTableRow = "table_row"
 	// BlockCollectionView is a collection view block
 	BlockCollectionView = "collection_view"
 	// BlockCollectionViewPage is a page that is a collection
@@ -81,21 +84,38 @@ const (
 	BlockFactory = "factory"
 	// BlockFigma represents figma embed
 	BlockFigma = "figma"
+	// BlockTransclusionContainer is the original of a synced block
+	BlockTransclusionContainer = "transclusion_container"
+	// BlockTransclusionReference is a copy of a synced block. Its content
+	// is the content of BlockTransclusionContainer (see SyncedFromID)
+	BlockTransclusionReference = "transclusion_reference"
+	// BlockAlias is a "link to page" block. See AliasPointerID
+	BlockAlias = "alias"
 )
 
 // FormatToggle describes format for BlockToggle
 type FormatToggle struct {
 	BlockColor string `json:"block_color"`
+	// true if toggle is expanded
+	ToggleOpen bool `json:"toggle_open,omitempty"`
 }
 
 // FormatNumberedList describes format for BlockNumberedList
 type FormatNumberedList struct {
 	BlockColor string `json:"block_color"`
+	// true if children of the list item can be collapsed, like in a toggle
+	Toggleable bool `json:"toggleable,omitempty"`
+	// true if toggleable list item is expanded
+	ToggleOpen bool `json:"toggle_open,omitempty"`
 }
 
 // FormatBulletedList describes format for BlockBulletedList
 type FormatBulletedList struct {
 	BlockColor string `json:"block_color"`
+	// true if children of the list item can be collapsed, like in a toggle
+	Toggleable bool `json:"toggleable,omitempty"`
+	// true if toggleable list item is expanded
+	ToggleOpen bool `json:"toggle_open,omitempty"`
 }
 
 // FormatPage describes format for BlockPage
@@ -145,6 +165,9 @@ type FormatVideo struct {
 	BlockPageWidth     bool    `json:"block_page_width"`
 	BlockAspectRatio   float64 `json:"block_aspect_ratio"`
 	BlockPreserveScale bool    `json:"block_preserve_scale"`
+	// preview image of the video, if set
+	BlockThumbnail string `json:"block_thumbnail,omitempty"`
+	BlockCover     string `json:"block_cover,omitempty"`
 }
 
 // FormatText describes format for BlockText
@@ -163,6 +186,14 @@ type FormatHeader struct {
 type FormatTable struct {
 	TableWrap       bool             `json:"table_wrap"`
 	TableProperties []*TableProperty `json:"table_properties"`
+
+	// ids of columns, in display order. Cells of BlockTableRow are
+	// stored in Properties under those ids
+	ColumnOrder []string `json:"table_block_column_order"`
+	// if true, first row is a header row
+	ColumnHeader bool `json:"table_block_column_header"`
+	// if true, first column is a header column
+	RowHeader bool `json:"table_block_row_header"`
 }
 
 // FormatColumn describes format for BlockColumn
@@ -321,6 +352,25 @@ func (b *Block) PropAsString(key string) (string, bool) {
 	return s, ok
 }
 
+// SyncedFromID returns id of BlockTransclusionContainer a
+// BlockTransclusionReference is a copy of or empty string
+func (b *Block) SyncedFromID() string {
+	if b.Type != BlockTransclusionReference {
+		return ""
+	}
+	id, _ := b.PropAsString("format.transclusion_reference_pointer.id")
+	return id
+}
+
+// AliasPointerID returns id of a page BlockAlias links to or empty string
+func (b *Block) AliasPointerID() string {
+	if b.Type != BlockAlias {
+		return ""
+	}
+	id, _ := b.PropAsString("format.alias_pointer.id")
+	return id
+}
+
 // CreatedOn return the time the page was created
 func (b *Block) CreatedOn() time.Time {
 	return time.Unix(b.CreatedTime/1000, 0)
@@ -356,6 +406,14 @@ func (b *Block) IsPage() bool {
 	return b.Type == BlockPage
 }
 
+// IsLocked returns true if the block (e.g. a page) is locked
+// i.e. it can't be edited until unlocked
+func (b *Block) IsLocked() bool {
+	v, _ := b.Prop("format.block_locked")
+	locked, _ := v.(bool)
+	return locked
+}
+
 // IsImage returns true if block represents an image
 func (b *Block) IsImage() bool {
 	return b.Type == BlockImage
@@ -391,6 +449,111 @@ func (b *Block) GetProperty(name string) []*TextSpan {
 	return ts
 }
 
+// Collection returns a collection (database) this block is a row of or
+// nil if it's not a row or we don't have the collection
+func (b *Block) Collection() *Collection {
+	if b.ParentTable != TableCollection || b.Page == nil {
+		return nil
+	}
+	return b.Page.idToCollection[b.ParentID]
+}
+
+// propertyID returns id of property (column) of a collection row.
+// colName can be a name of the column (as shown in Notion) or its id
+func (b *Block) propertyID(colName string) (string, error) {
+	if _, ok := b.Properties[colName]; ok {
+		return colName, nil
+	}
+	coll := b.Collection()
+	if coll == nil {
+		return "", fmt.Errorf("block '%s' is not a row of a known collection and has no property '%s'", b.ID, colName)
+	}
+	if _, ok := coll.CollectionSchema[colName]; ok {
+		return colName, nil
+	}
+	for id, col := range coll.CollectionSchema {
+		if col.Name == colName {
+			return id, nil
+		}
+	}
+	return "", fmt.Errorf("collection '%s' has no column '%s'", coll.ID, colName)
+}
+
+// GetPropertyValue returns value of a property of a collection row.
+// colName can be a name of the column or its id.
+// An empty cell returns nil and no error
+func (b *Block) GetPropertyValue(colName string) ([]*TextSpan, error) {
+	id, err := b.propertyID(colName)
+	if err != nil {
+		return nil, err
+	}
+	v, ok := b.Properties[id]
+	if !ok {
+		return nil, nil
+	}
+	return ParseTextSpans(v)
+}
+
+// GetPropertyAsString returns value of a property of a collection row as
+// plain text
+func (b *Block) GetPropertyAsString(colName string) (string, error) {
+	ts, err := b.GetPropertyValue(colName)
+	if err != nil {
+		return "", err
+	}
+	return TextSpansToString(ts), nil
+}
+
+// GetPropertyAsNumber returns value of a number property of a collection
+// row. An empty cell is 0
+func (b *Block) GetPropertyAsNumber(colName string) (float64, error) {
+	s, err := b.GetPropertyAsString(colName)
+	if err != nil || s == "" {
+		return 0, err
+	}
+	n, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	if err != nil {
+		return 0, fmt.Errorf("value '%s' of property '%s' is not a number", s, colName)
+	}
+	return n, nil
+}
+
+// GetPropertyAsBool returns value of a checkbox property of a collection
+// row. An empty cell is false
+func (b *Block) GetPropertyAsBool(colName string) (bool, error) {
+	s, err := b.GetPropertyAsString(colName)
+	if err != nil {
+		return false, err
+	}
+	switch s {
+	case "Yes":
+		return true, nil
+	case "No", "":
+		return false, nil
+	}
+	return false, fmt.Errorf("value '%s' of property '%s' is not a checkbox value", s, colName)
+}
+
+// GetPropertyAsDate returns value of a date property of a collection row.
+// An empty cell is nil
+func (b *Block) GetPropertyAsDate(colName string) (*Date, error) {
+	ts, err := b.GetPropertyValue(colName)
+	if err != nil {
+		return nil, err
+	}
+	for _, span := range ts {
+		for _, attr := range span.Attrs {
+			if AttrGetType(attr) == AttrDate {
+				return AttrGetDate(attr), nil
+			}
+		}
+	}
+	if len(ts) == 0 {
+		return nil, nil
+	}
+	return nil, fmt.Errorf("property '%s' is not a date", colName)
+}
+
 func (b *Block) GetCaption() []*TextSpan {
 	return b.GetProperty("caption")
 }
@@ -399,6 +562,14 @@ func (b *Block) GetTitle() []*TextSpan {
 	return b.GetProperty("title")
 }
 
+// PlainText returns InlineContent of the block as plain text
+func (b *Block) PlainText() string {
+	if b == nil {
+		return ""
+	}
+	return TextSpansToString(b.InlineContent)
+}
+
 func parseTitle(block *Block) error {
 	// has already been parsed
 	if block.InlineContent != nil {
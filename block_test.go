@@ -0,0 +1,118 @@
+package notionapi
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestCollectionRow() *Block {
+	coll := &Collection{
+		ID: "00000000-0000-0000-0000-0000000000c0",
+		CollectionSchema: map[string]*CollectionColumnInfo{
+			"title": {Name: "Name", Type: ColumnTypeTitle},
+			"Xa;b":  {Name: "Price", Type: ColumnTypeNumber},
+			"<Q>t":  {Name: "Done", Type: "checkbox"},
+			"d:Ue":  {Name: "Due", Type: "date"},
+			"n0te":  {Name: "Notes", Type: "text"},
+		},
+	}
+	root := newTestBlock("00000000000000000000000000000001", BlockPage, "Page")
+	page := newTestPage(root)
+	page.idToCollection[coll.ID] = coll
+
+	row := newTestBlock("00000000000000000000000000000002", BlockPage, "Milk")
+	row.ParentID = coll.ID
+	row.ParentTable = TableCollection
+	row.Page = page
+	row.Properties["Xa;b"] = []interface{}{[]interface{}{"2.5"}}
+	row.Properties["<Q>t"] = []interface{}{[]interface{}{"Yes"}}
+	row.Properties["d:Ue"] = []interface{}{
+		[]interface{}{"‣", []interface{}{
+			[]interface{}{"d", map[string]interface{}{"type": "date", "start_date": "2020-05-18"}},
+		}},
+	}
+	return row
+}
+
+func TestGetPropertyAsString(t *testing.T) {
+	row := newTestCollectionRow()
+	s, err := row.GetPropertyAsString("Name")
+	assert.NoError(t, err)
+	assert.Equal(t, "Milk", s)
+	// can also use column id
+	s, err = row.GetPropertyAsString("title")
+	assert.NoError(t, err)
+	assert.Equal(t, "Milk", s)
+	// empty cell
+	s, err = row.GetPropertyAsString("Notes")
+	assert.NoError(t, err)
+	assert.Equal(t, "", s)
+
+	_, err = row.GetPropertyAsString("Missing")
+	assert.Error(t, err)
+}
+
+func TestGetPropertyAsNumber(t *testing.T) {
+	row := newTestCollectionRow()
+	n, err := row.GetPropertyAsNumber("Price")
+	assert.NoError(t, err)
+	assert.Equal(t, 2.5, n)
+
+	_, err = row.GetPropertyAsNumber("Name")
+	assert.Error(t, err)
+}
+
+func TestGetPropertyAsBool(t *testing.T) {
+	row := newTestCollectionRow()
+	v, err := row.GetPropertyAsBool("Done")
+	assert.NoError(t, err)
+	assert.True(t, v)
+
+	delete(row.Properties, "<Q>t")
+	v, err = row.GetPropertyAsBool("Done")
+	assert.NoError(t, err)
+	assert.False(t, v)
+}
+
+func TestGetPropertyAsDate(t *testing.T) {
+	row := newTestCollectionRow()
+	d, err := row.GetPropertyAsDate("Due")
+	assert.NoError(t, err)
+	assert.Equal(t, "2020-05-18", d.StartDate)
+
+	d, err = row.GetPropertyAsDate("Notes")
+	assert.NoError(t, err)
+	assert.Nil(t, d)
+
+	_, err = row.GetPropertyAsDate("Name")
+	assert.Error(t, err)
+}
+
+func TestIsLocked(t *testing.T) {
+	b := &Block{RawJSON: map[string]interface{}{
+		"format": map[string]interface{}{"block_locked": true},
+	}}
+	assert.True(t, b.IsLocked())
+
+	b.RawJSON["format"] = map[string]interface{}{"block_locked": false}
+	assert.False(t, b.IsLocked())
+
+	b = &Block{RawJSON: map[string]interface{}{}}
+	assert.False(t, b.IsLocked())
+}
+
+func TestPlainText(t *testing.T) {
+	b := &Block{
+		InlineContent: []*TextSpan{
+			{Text: "bold", Attrs: []TextAttr{{AttrBold}}},
+			{Text: " and plain"},
+		},
+	}
+	assert.Equal(t, TextSpansToString(b.InlineContent), b.PlainText())
+	assert.Equal(t, "bold and plain", b.PlainText())
+
+	assert.Equal(t, "", (&Block{}).PlainText())
+	var nilBlock *Block
+	assert.Equal(t, "", nilBlock.PlainText())
+}
@@ -1,6 +1,7 @@
 package caching_downloader
 
 import (
+	"context"
 	"crypto/sha1"
 	"fmt"
 	"path/filepath"
@@ -196,7 +197,7 @@ func (d *Downloader) checkVersionsOfCachedPages() error {
 	return nil
 }
 
-func (d *Downloader) readPageFromDisk(pageID string) (*notionapi.Page, error) {
+func (d *Downloader) readPageFromDisk(ctx context.Context, pageID string) (*notionapi.Page, error) {
 	name := d.nameForPageID(pageID)
 
 	data, err := d.Cache.ReadFile(name)
@@ -213,7 +214,7 @@ func (d *Downloader) readPageFromDisk(pageID string) (*notionapi.Page, error) {
 	nPrevRequestsFromCache := httpCache.RequestsNotFromCache
 	c := d.GetClientCopy()
 	c.HTTPClient = caching_http_client.New(httpCache)
-	page, err := c.DownloadPage(pageID)
+	page, err := c.DownloadPageCtx(ctx, pageID)
 	if err != nil {
 		return nil, err
 	}
@@ -247,7 +248,7 @@ func (d *Downloader) canReturnCachedPage(p *notionapi.Page) bool {
 	return pageVer >= newestVer
 }
 
-func (d *Downloader) getPageFromCache(pageID string) *notionapi.Page {
+func (d *Downloader) getPageFromCache(ctx context.Context, pageID string) *notionapi.Page {
 	if !d.useReadCache() {
 		return nil
 	}
@@ -256,7 +257,7 @@ func (d *Downloader) getPageFromCache(pageID string) *notionapi.Page {
 	if d.canReturnCachedPage(p) {
 		return p
 	}
-	p, err := d.readPageFromDisk(pageID)
+	p, err := d.readPageFromDisk(ctx, pageID)
 	if err != nil {
 		return nil
 	}
@@ -268,21 +269,29 @@ func (d *Downloader) getPageFromCache(pageID string) *notionapi.Page {
 
 // I got "connection reset by peer" error once so retry download 3 times
 // with a short sleep in-between
-func (d *Downloader) downloadPageRetry(pageID string) (*notionapi.Page, *caching_http_client.Cache, error) {
+func (d *Downloader) downloadPageRetry(ctx context.Context, pageID string) (*notionapi.Page, *caching_http_client.Cache, error) {
 	var res *notionapi.Page
 	var err error
 	for i := 0; i < 3; i++ {
 		c := d.GetClientCopy()
 		httpCache := caching_http_client.NewCache()
 		c.HTTPClient = caching_http_client.New(httpCache)
-		res, err = c.DownloadPage(pageID)
+		res, err = c.DownloadPageCtx(ctx, pageID)
 		if err == nil {
 			return res, httpCache, nil
 		}
+		// no point retrying if the caller gave up
+		if ctx.Err() != nil {
+			return nil, nil, ctx.Err()
+		}
 		// only report errors on the first failure
 		if i == 0 {
 			d.emitError("Download %s failed with '%s'\n", pageID, err)
-			time.Sleep(5 * time.Second) // not sure if it matters
+			select {
+			case <-time.After(5 * time.Second): // not sure if it matters
+			case <-ctx.Done():
+				return nil, nil, ctx.Err()
+			}
 		}
 		// don't retry if it can't succeed
 		// TODO: probably should change to check for temporary
@@ -312,9 +321,9 @@ func (d *Downloader) emitError(format string, args ...interface{}) {
 	d.emitEvent(ev)
 }
 
-func (d *Downloader) downloadAndCachePage(pageID string) (*notionapi.Page, error) {
+func (d *Downloader) downloadAndCachePage(ctx context.Context, pageID string) (*notionapi.Page, error) {
 	pageID = notionapi.ToNoDashID(pageID)
-	page, httpCache, err := d.downloadPageRetry(pageID)
+	page, httpCache, err := d.downloadPageRetry(ctx, pageID)
 	if err != nil {
 		return nil, err
 	}
@@ -333,13 +342,18 @@ func (d *Downloader) downloadAndCachePage(pageID string) (*notionapi.Page, error
 }
 
 func (d *Downloader) DownloadPage(pageID string) (*notionapi.Page, error) {
+	return d.DownloadPageCtx(context.Background(), pageID)
+}
+
+// DownloadPageCtx is like DownloadPage but can be cancelled via ctx
+func (d *Downloader) DownloadPageCtx(ctx context.Context, pageID string) (*notionapi.Page, error) {
 	pageID = notionapi.ToNoDashID(pageID)
 	timeStart := time.Now()
-	page := d.getPageFromCache(pageID)
+	page := d.getPageFromCache(ctx, pageID)
 	if page == nil {
 		var err error
 		timeStart = time.Now()
-		page, err = d.downloadAndCachePage(pageID)
+		page, err = d.downloadAndCachePage(ctx, pageID)
 		if err != nil {
 			return nil, err
 		}
@@ -2,6 +2,7 @@ package notionapi
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -23,25 +24,70 @@ const (
 type Client struct {
 	// AuthToken allows accessing non-public pages.
 	AuthToken string
+	// TokenProvider, if set, is called before every request to get
+	// the auth token (token_v2) and is used instead of AuthToken.
+	// Allows refreshing expired tokens in long running programs
+	TokenProvider func() (string, error)
 	// HTTPClient allows over-riding http.Client to e.g. implement caching
 	// on a per-request level
 	HTTPClient *http.Client
+	// CookieJar, if set, is used to send and store cookies
+	CookieJar http.CookieJar
 	// Logger is used to log requests and responses for debugging.
 	// By default is not set.
 	Logger io.Writer
 	// DebugLog enables debug logging
 	DebugLog bool
+	// TaskTimeout is the maximum time we wait for server tasks started
+	// by DuplicatePage and ExportPage. DefaultTaskTimeout if 0
+	TaskTimeout time.Duration
+}
+
+func (c *Client) getTaskTimeout() time.Duration {
+	if c.TaskTimeout > 0 {
+		return c.TaskTimeout
+	}
+	return DefaultTaskTimeout
 }
 
 func (c *Client) getHTTPClient() *http.Client {
 	if c.HTTPClient != nil {
-		return c.HTTPClient
+		if c.CookieJar == nil {
+			return c.HTTPClient
+		}
+		httpClient := *c.HTTPClient
+		httpClient.Jar = c.CookieJar
+		return &httpClient
 	}
 	httpClient := *http.DefaultClient
 	httpClient.Timeout = time.Second * 30
+	httpClient.Jar = c.CookieJar
 	return &httpClient
 }
 
+// getAuthToken returns the token from TokenProvider or AuthToken
+func (c *Client) getAuthToken() (string, error) {
+	if c.TokenProvider != nil {
+		token, err := c.TokenProvider()
+		if err != nil {
+			return "", fmt.Errorf("TokenProvider() failed with %s", err)
+		}
+		return token, nil
+	}
+	return c.AuthToken, nil
+}
+
+func (c *Client) setAuthCookie(req *http.Request) error {
+	token, err := c.getAuthToken()
+	if err != nil {
+		return err
+	}
+	if token != "" {
+		req.Header.Set("cookie", fmt.Sprintf("token_v2=%v", token))
+	}
+	return nil
+}
+
 // ErrPageNotFound is returned by Client.DownloadPage if page
 // cannot be found
 type ErrPageNotFound struct {
@@ -65,7 +111,103 @@ func IsErrPageNotFound(err error) bool {
 	return ok
 }
 
+// APIError is returned when Notion API responds with non-200 status code
+type APIError struct {
+	URL        string `json:"-"`
+	StatusCode int    `json:"-"`
+	// those come from JSON body of the response, if Notion sent it
+	Name    string `json:"name"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+func newAPIError(uri string, statusCode int, body []byte) *APIError {
+	res := &APIError{}
+	// it's fine if the body is not JSON or is missing fields
+	_ = json.Unmarshal(body, res)
+	res.URL = uri
+	res.StatusCode = statusCode
+	return res
+}
+
+// Error return error string
+func (e *APIError) Error() string {
+	s := fmt.Sprintf("http.Post('%s') returned non-200 status code of %d", e.URL, e.StatusCode)
+	if e.Name != "" {
+		s += ", " + e.Name
+	}
+	if e.Message != "" {
+		s += ": " + e.Message
+	}
+	return s
+}
+
+func isAPIErrorWithStatus(err error, statusCode int) bool {
+	e, ok := err.(*APIError)
+	return ok && e.StatusCode == statusCode
+}
+
+// IsNotFound returns true if err is APIError with 404 status code
+func IsNotFound(err error) bool {
+	return isAPIErrorWithStatus(err, http.StatusNotFound)
+}
+
+// IsUnauthorized returns true if err is APIError with 401 status code
+// e.g. when AuthToken is invalid or expired
+func IsUnauthorized(err error) bool {
+	return isAPIErrorWithStatus(err, http.StatusUnauthorized)
+}
+
+// IsRateLimited returns true if err is APIError with 429 status code
+func IsRateLimited(err error) bool {
+	return isAPIErrorWithStatus(err, http.StatusTooManyRequests)
+}
+
 func doNotionAPI(c *Client, apiURL string, requestData interface{}, result interface{}) (map[string]interface{}, error) {
+	return doNotionAPICtx(context.Background(), c, apiURL, requestData, result)
+}
+
+// doNotionAPICtx is like doNotionAPI but the request is aborted
+// when ctx is cancelled
+func doNotionAPICtx(ctx context.Context, c *Client, apiURL string, requestData interface{}, result interface{}) (map[string]interface{}, error) {
+	rsp, err := doNotionAPIRequest(ctx, c, apiURL, requestData)
+	if err != nil {
+		return nil, err
+	}
+	defer rsp.Body.Close()
+	d, err := ioutil.ReadAll(rsp.Body)
+	if err != nil {
+		log(c, "Error: ioutil.ReadAll() failed with %s\n", err)
+		return nil, err
+	}
+	logJSON(c, d)
+	err = json.Unmarshal(d, result)
+	if err != nil {
+		log(c, "Error: json.Unmarshal() failed with %s\n. Body:\n%s\n", err, string(d))
+		return nil, err
+	}
+	var m map[string]interface{}
+	err = json.Unmarshal(d, &m)
+	if err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// doNotionAPIStreamCtx is like doNotionAPICtx but instead of reading the
+// whole response in memory, it calls fn with the body of the response
+func doNotionAPIStreamCtx(ctx context.Context, c *Client, apiURL string, requestData interface{}, fn func(io.Reader) error) error {
+	rsp, err := doNotionAPIRequest(ctx, c, apiURL, requestData)
+	if err != nil {
+		return err
+	}
+	defer rsp.Body.Close()
+	return fn(rsp.Body)
+}
+
+// doNotionAPIRequest sends the request and returns the response if it
+// has 200 status code. Caller must close rsp.Body
+func doNotionAPIRequest(ctx context.Context, c *Client, apiURL string, requestData interface{}) (*http.Response, error) {
 	var js []byte
 	var err error
 	if requestData != nil {
@@ -81,15 +223,15 @@ func doNotionAPI(c *Client, apiURL string, requestData interface{}, result inter
 		logJSON(c, js)
 	}
 
-	req, err := http.NewRequest("POST", uri, body)
+	req, err := http.NewRequestWithContext(ctx, "POST", uri, body)
 	if err != nil {
 		return nil, err
 	}
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("User-Agent", userAgent)
 	req.Header.Set("Accept-Language", acceptLang)
-	if c.AuthToken != "" {
-		req.Header.Set("cookie", fmt.Sprintf("token_v2=%v", c.AuthToken))
+	if err = c.setAuthCookie(req); err != nil {
+		return nil, err
 	}
 	var rsp *http.Response
 
@@ -100,29 +242,13 @@ func doNotionAPI(c *Client, apiURL string, requestData interface{}, result inter
 		log(c, "http.DefaultClient.Do() failed with %s\n", err)
 		return nil, err
 	}
-	defer rsp.Body.Close()
 	if rsp.StatusCode != 200 {
 		d, _ := ioutil.ReadAll(rsp.Body)
+		rsp.Body.Close()
 		log(c, "Error: status code %s\nBody:\n%s\n", rsp.Status, ppJSON(d))
-		return nil, fmt.Errorf("http.Post('%s') returned non-200 status code of %d", uri, rsp.StatusCode)
+		return nil, newAPIError(uri, rsp.StatusCode, d)
 	}
-	d, err := ioutil.ReadAll(rsp.Body)
-	if err != nil {
-		log(c, "Error: ioutil.ReadAll() failed with %s\n", err)
-		return nil, err
-	}
-	logJSON(c, d)
-	err = json.Unmarshal(d, result)
-	if err != nil {
-		log(c, "Error: json.Unmarshal() failed with %s\n. Body:\n%s\n", err, string(d))
-		return nil, err
-	}
-	var m map[string]interface{}
-	err = json.Unmarshal(d, &m)
-	if err != nil {
-		return nil, err
-	}
-	return m, nil
+	return rsp, nil
 }
 
 var (
@@ -307,6 +433,12 @@ func (p *Page) findMissingBlocks() []string {
 				missing[id] = struct{}{}
 			}
 		}
+		// original of a synced block can be in a different page
+		if id := block.SyncedFromID(); id != "" {
+			if _, ok := p.idToBlock[id]; !ok {
+				missing[id] = struct{}{}
+			}
+		}
 		referencedPages := p.findInlinePageReferences(block)
 		for _, id := range referencedPages {
 			if _, ok := p.idToBlock[id]; !ok {
@@ -328,6 +460,42 @@ func (p *Page) findMissingBlocks() []string {
 
 // DownloadPage returns Notion page data given its id
 func (c *Client) DownloadPage(pageID string) (*Page, error) {
+	return c.DownloadPageCtx(context.Background(), pageID)
+}
+
+// DownloadPageCtx is like DownloadPage but can be cancelled via ctx
+func (c *Client) DownloadPageCtx(ctx context.Context, pageID string) (*Page, error) {
+	return c.downloadPage(ctx, pageID, nil, nil)
+}
+
+// LoadPageOptions are options for LoadPage
+type LoadPageOptions struct {
+	// if false, rows of collections (databases) are not downloaded.
+	// CollectionViews of BlockCollectionView blocks still describe
+	// the views and collections but have no CollectionRows.
+	// Skipping them is much faster for pages with large databases
+	LoadCollectionRows bool
+}
+
+// LoadPage is like DownloadPage but allows to skip downloading some
+// data. nil opts is the same as DownloadPage i.e. everything is downloaded
+func (c *Client) LoadPage(pageID string, opts *LoadPageOptions) (*Page, error) {
+	return c.downloadPage(context.Background(), pageID, opts, nil)
+}
+
+// DownloadPageStreaming is like DownloadPage but calls onChunk with blocks
+// as they are downloaded (in chunks, the first one has the root block)
+// so that a page can be shown before it's fully downloaded.
+// Blocks passed to onChunk are not fully resolved e.g. Content is not set
+// (use ContentIDs) and collection views don't have data
+func (c *Client) DownloadPageStreaming(pageID string, onChunk func(blocks []*Block)) (*Page, error) {
+	return c.downloadPage(context.Background(), pageID, nil, onChunk)
+}
+
+func (c *Client) downloadPage(ctx context.Context, pageID string, opts *LoadPageOptions, onChunk func(blocks []*Block)) (*Page, error) {
+	if opts == nil {
+		opts = &LoadPageOptions{LoadCollectionRows: true}
+	}
 	id := ToDashID(pageID)
 	if !IsValidDashID(id) {
 		return nil, fmt.Errorf("%s is not a valid Notion page id", id)
@@ -344,10 +512,27 @@ func (c *Client) DownloadPage(pageID string) (*Page, error) {
 		blocksToSkip:       map[string]struct{}{},
 	}
 
+	// blocks not yet passed to onChunk
+	var pending []*Block
+	flushPending := func() {
+		if onChunk == nil || len(pending) == 0 {
+			return
+		}
+		sort.Slice(pending, func(i, j int) bool {
+			return pending[i].ID < pending[j].ID
+		})
+		for _, b := range pending {
+			parseProperties(b)
+			b.Page = p
+		}
+		onChunk(pending)
+		pending = nil
+	}
+
 	var root *Block
 	// get page's root block and then recursively download referenced blocks
 	{
-		recVals, err := c.GetRecordValues([]string{pageID})
+		recVals, err := c.getRecordValuesCtx(ctx, []string{pageID})
 		if err != nil {
 			return nil, err
 		}
@@ -359,18 +544,22 @@ func (c *Client) DownloadPage(pageID string) (*Page, error) {
 		}
 		panicIf(p.ID != root.ID, "%s != %s", p.ID, root.ID)
 		p.idToBlock[root.ID] = root
+		pending = append(pending, root)
 	}
 
 	chunkNo := 0
 	var cur *cursor
 	for {
-		rsp, err := c.LoadPageChunk(pageID, chunkNo, cur)
+		rsp, err := c.loadPageChunkCtx(ctx, pageID, chunkNo, cur)
 		chunkNo++
 		if err != nil {
 			return nil, err
 		}
 		for id, v := range rsp.RecordMap.Blocks {
 			if v.Value.Alive {
+				if _, ok := p.idToBlock[id]; !ok {
+					pending = append(pending, v.Value)
+				}
 				p.idToBlock[id] = v.Value
 			} else {
 				p.blocksToSkip[id] = struct{}{}
@@ -391,6 +580,7 @@ func (c *Client) DownloadPage(pageID string) (*Page, error) {
 		for id, v := range rsp.RecordMap.Users {
 			p.idToUser[id] = v.Value
 		}
+		flushPending()
 
 		cursor := rsp.Cursor
 		//dbg("GetPaDownloadPagegeInfo: len(cursor.Stack)=%d\n", len(cursor.Stack))
@@ -422,7 +612,7 @@ func (c *Client) DownloadPage(pageID string) (*Page, error) {
 				missing = nil
 			}
 
-			recVals, err := c.GetRecordValues(toGet)
+			recVals, err := c.getRecordValuesCtx(ctx, toGet)
 			if err != nil {
 				return nil, err
 			}
@@ -433,6 +623,7 @@ func (c *Client) DownloadPage(pageID string) (*Page, error) {
 				expectedID := toGet[n]
 				if block != nil {
 					p.idToBlock[block.ID] = block
+					pending = append(pending, block)
 					continue
 				}
 				p.blocksToSkip[expectedID] = struct{}{}
@@ -449,6 +640,7 @@ func (c *Client) DownloadPage(pageID string) (*Page, error) {
 					dbg(c, "block is nil at position n = %v with expected id %s.\n", n, expectedID)
 				}
 			}
+			flushPending()
 		}
 	}
 
@@ -484,11 +676,19 @@ func (c *Client) DownloadPage(pageID string) (*Page, error) {
 				//return nil, fmt.Errorf("Didn't find collection with id '%s'", collectionID)
 				continue
 			}
+			if !opts.LoadCollectionRows {
+				collInfo := &CollectionViewInfo{
+					CollectionView: collectionView,
+					Collection:     collection,
+				}
+				block.CollectionViews = append(block.CollectionViews, collInfo)
+				continue
+			}
 			var agg []*AggregateQuery
 			if collectionView.Query != nil {
 				agg = collectionView.Query.Aggregate
 			}
-			res, err := c.QueryCollection(collectionID, collectionViewID, agg, user)
+			res, err := c.queryCollectionCtx(ctx, collectionID, collectionViewID, agg, user)
 			if err != nil {
 				return nil, err
 			}
@@ -505,7 +705,11 @@ func (c *Client) DownloadPage(pageID string) (*Page, error) {
 				if !ok {
 					return nil, fmt.Errorf("didn't find block with id '%s' for collection view with id '%s'", id, collectionViewID)
 				}
-				collInfo.CollectionRows = append(collInfo.CollectionRows, rowBlock.Value)
+				row := rowBlock.Value
+				if row != nil {
+					row.Page = p
+				}
+				collInfo.CollectionRows = append(collInfo.CollectionRows, row)
 			}
 			block.CollectionViews = append(block.CollectionViews, collInfo)
 		}
@@ -1,11 +1,35 @@
 package notionapi
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
+// roundTripFunc allows faking http responses in tests
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
+
+func newTestClient(fn roundTripFunc) *Client {
+	return &Client{
+		HTTPClient: &http.Client{Transport: fn},
+	}
+}
+
 func TestExtractNoDashIDFromNotionURL(t *testing.T) {
 	tests := [][]string{
 		{
@@ -39,3 +63,224 @@ func TestExtractNoDashIDFromNotionURL(t *testing.T) {
 		assert.Equal(t, exp, got)
 	}
 }
+
+func TestDownloadPageCtxCancel(t *testing.T) {
+	started := make(chan struct{})
+	client := newTestClient(func(r *http.Request) (*http.Response, error) {
+		close(started)
+		// simulate a server that never responds
+		<-r.Context().Done()
+		return nil, r.Context().Err()
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-started
+		cancel()
+	}()
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := client.DownloadPageCtx(ctx, "4c6a54c68b3e4ea2af9cfaabcc88d58d")
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		assert.Error(t, err)
+		assert.Equal(t, context.Canceled, ctx.Err())
+	case <-time.After(5 * time.Second):
+		t.Fatal("DownloadPageCtx didn't return after context was cancelled")
+	}
+}
+
+func TestNewAPIError(t *testing.T) {
+	body := `{"errorId":"8a0c4b5e-7e3c-4b7e-9bb0-6b4b1c2c9e2f","name":"UnauthorizedError","message":"Token was invalid or expired."}`
+	err := newAPIError("https://www.notion.so/api/v3/loadUserContent", 401, []byte(body))
+	assert.Equal(t, 401, err.StatusCode)
+	assert.Equal(t, "UnauthorizedError", err.Name)
+	assert.Equal(t, "Token was invalid or expired.", err.Message)
+	assert.True(t, IsUnauthorized(err))
+	assert.False(t, IsNotFound(err))
+	assert.Contains(t, err.Error(), "Token was invalid or expired.")
+
+	body = `{"name":"RateLimitedError","code":"rate_limited","message":"Please try again later."}`
+	err = newAPIError("https://www.notion.so/api/v3/loadPageChunk", 429, []byte(body))
+	assert.Equal(t, "rate_limited", err.Code)
+	assert.True(t, IsRateLimited(err))
+
+	// body is not always JSON
+	err = newAPIError("https://www.notion.so/api/v3/getRecordValues", 404, []byte("<html>not found</html>"))
+	assert.True(t, IsNotFound(err))
+	assert.Equal(t, "", err.Message)
+}
+
+func TestDoNotionAPIReturnsAPIError(t *testing.T) {
+	client := newTestClient(func(r *http.Request) (*http.Response, error) {
+		body := `{"name":"RateLimitedError","message":"Please try again later."}`
+		return &http.Response{
+			StatusCode: 429,
+			Status:     "429 Too Many Requests",
+			Body:       ioutil.NopCloser(strings.NewReader(body)),
+			Header:     http.Header{},
+		}, nil
+	})
+	_, err := client.LoadUserContent()
+	assert.True(t, IsRateLimited(err))
+	apiErr, ok := err.(*APIError)
+	assert.True(t, ok)
+	assert.Equal(t, "Please try again later.", apiErr.Message)
+}
+
+func TestTokenProvider(t *testing.T) {
+	var cookies []string
+	client := newTestClient(func(r *http.Request) (*http.Response, error) {
+		cookies = append(cookies, r.Header.Get("cookie"))
+		return jsonResponse(map[string]interface{}{"results": []interface{}{}})
+	})
+	client.AuthToken = "static"
+	_, err := client.GetRecordValues(nil)
+	require.NoError(t, err)
+
+	n := 0
+	client.TokenProvider = func() (string, error) {
+		n++
+		return fmt.Sprintf("token%d", n), nil
+	}
+	_, err = client.GetRecordValues(nil)
+	require.NoError(t, err)
+	_, err = client.GetRecordValues(nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"token_v2=static", "token_v2=token1", "token_v2=token2"}, cookies)
+
+	client.TokenProvider = func() (string, error) {
+		return "", errors.New("expired")
+	}
+	_, err = client.GetRecordValues(nil)
+	assert.Error(t, err)
+	assert.Len(t, cookies, 3)
+}
+
+func TestCookieJar(t *testing.T) {
+	jar, err := cookiejar.New(nil)
+	require.NoError(t, err)
+	u, _ := url.Parse("https://www.notion.so/")
+	jar.SetCookies(u, []*http.Cookie{{Name: "notion_browser_id", Value: "abc"}})
+
+	var cookie string
+	client := newTestClient(func(r *http.Request) (*http.Response, error) {
+		cookie = r.Header.Get("cookie")
+		return jsonResponse(map[string]interface{}{"results": []interface{}{}})
+	})
+	client.CookieJar = jar
+	_, err = client.GetRecordValues(nil)
+	require.NoError(t, err)
+	assert.Contains(t, cookie, "notion_browser_id=abc")
+}
+
+func TestDownloadPageStreaming(t *testing.T) {
+	rootID := "00000000-0000-0000-0000-000000000001"
+	block := func(id string, title string, content ...string) map[string]interface{} {
+		return map[string]interface{}{
+			"role": "reader",
+			"value": map[string]interface{}{
+				"id":         id,
+				"type":       BlockText,
+				"alive":      true,
+				"content":    content,
+				"properties": map[string]interface{}{"title": []interface{}{[]interface{}{title}}},
+			},
+		}
+	}
+	root := block(rootID, "root", "00000000-0000-0000-0000-000000000002", "00000000-0000-0000-0000-000000000003")
+	root["value"].(map[string]interface{})["type"] = BlockPage
+	chunks := []map[string]interface{}{
+		{
+			rootID:                                 root,
+			"00000000-0000-0000-0000-000000000002": block("00000000-0000-0000-0000-000000000002", "first"),
+		},
+		{
+			"00000000-0000-0000-0000-000000000003": block("00000000-0000-0000-0000-000000000003", "second"),
+		},
+	}
+	client := newTestClient(func(r *http.Request) (*http.Response, error) {
+		d, _ := ioutil.ReadAll(r.Body)
+		switch r.URL.Path {
+		case "/api/v3/getRecordValues":
+			return jsonResponse(map[string]interface{}{"results": []interface{}{root}})
+		case "/api/v3/loadPageChunk":
+			var req loadPageChunkRequest
+			_ = json.Unmarshal(d, &req)
+			stack := []interface{}{}
+			if req.ChunkNumber == 0 {
+				stack = append(stack, []interface{}{map[string]interface{}{"id": rootID, "index": 1, "table": "block"}})
+			}
+			return jsonResponse(map[string]interface{}{
+				"cursor":    map[string]interface{}{"stack": stack},
+				"recordMap": map[string]interface{}{"block": chunks[req.ChunkNumber]},
+			})
+		}
+		return jsonResponse(map[string]interface{}{})
+	})
+
+	var got [][]string
+	page, err := client.DownloadPageStreaming(rootID, func(blocks []*Block) {
+		var titles []string
+		for _, b := range blocks {
+			titles = append(titles, TextSpansToString(b.InlineContent))
+		}
+		got = append(got, titles)
+	})
+	require.NoError(t, err)
+	assert.Equal(t, [][]string{{"root", "first"}, {"second"}}, got)
+	assert.Len(t, page.Root().Content, 2)
+}
+
+func TestLoadPageSkipsCollectionRows(t *testing.T) {
+	rootID := "00000000-0000-0000-0000-000000000001"
+	cvBlockID := "00000000-0000-0000-0000-000000000002"
+	collectionID := "00000000-0000-0000-0000-000000000003"
+	viewID := "00000000-0000-0000-0000-000000000004"
+	record := func(v map[string]interface{}) map[string]interface{} {
+		v["alive"] = true
+		return map[string]interface{}{"role": "reader", "value": v}
+	}
+	recordMap := map[string]interface{}{
+		"block": map[string]interface{}{
+			rootID:    record(map[string]interface{}{"id": rootID, "type": BlockPage, "content": []string{cvBlockID}}),
+			cvBlockID: record(map[string]interface{}{"id": cvBlockID, "type": BlockCollectionView, "parent_id": rootID, "collection_id": collectionID, "view_ids": []string{viewID}}),
+		},
+		"collection": map[string]interface{}{
+			collectionID: record(map[string]interface{}{"id": collectionID}),
+		},
+		"collection_view": map[string]interface{}{
+			viewID: record(map[string]interface{}{"id": viewID, "type": "table"}),
+		},
+		"notion_user": map[string]interface{}{
+			"user": map[string]interface{}{"role": "reader", "value": map[string]interface{}{"id": "user"}},
+		},
+	}
+	nQueries := 0
+	client := newTestClient(func(r *http.Request) (*http.Response, error) {
+		switch r.URL.Path {
+		case "/api/v3/getRecordValues":
+			root := recordMap["block"].(map[string]interface{})[rootID]
+			return jsonResponse(map[string]interface{}{"results": []interface{}{root}})
+		case "/api/v3/loadPageChunk":
+			return jsonResponse(map[string]interface{}{
+				"cursor":    map[string]interface{}{"stack": []interface{}{}},
+				"recordMap": recordMap,
+			})
+		case "/api/v3/queryCollection":
+			nQueries++
+		}
+		return jsonResponse(map[string]interface{}{})
+	})
+
+	page, err := client.LoadPage(rootID, &LoadPageOptions{LoadCollectionRows: false})
+	require.NoError(t, err)
+	assert.Equal(t, 0, nQueries)
+	block := page.BlockByID(cvBlockID)
+	require.NotNil(t, block)
+	require.Len(t, block.CollectionViews, 1)
+	assert.Equal(t, viewID, block.CollectionViews[0].CollectionView.ID)
+	assert.Empty(t, block.CollectionViews[0].CollectionRows)
+}
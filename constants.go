@@ -24,6 +24,12 @@ const (
 	TableBlock = "block"
 	// TableUser represents a Notion user
 	TableUser = "notion_user"
+	// TableSpaceView represents a user's view of a Notion workspace
+	TableSpaceView = "space_view"
+	// TableCollection represents a Notion collection (database)
+	TableCollection = "collection"
+	// TableCollectionView represents a view of a Notion collection
+	TableCollectionView = "collection_view"
 )
 
 const (
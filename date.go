@@ -80,3 +80,28 @@ func FormatDate(d *Date) string {
 	}
 	return s
 }
+
+// FormatDateISO returns start of the date in ISO 8601 format, as used in
+// datetime attribute of <time> html element. Date without time is
+// formatted as "2021-03-04", date with time as "2021-03-04T10:00:00Z"
+// i.e. converted to UTC using d.TimeZone
+func FormatDateISO(d *Date) string {
+	if d.StartTime == "" {
+		return d.StartDate
+	}
+	s := d.StartDate + " " + d.StartTime
+	loc := time.UTC
+	if d.TimeZone != nil && *d.TimeZone != "" {
+		l, err := time.LoadLocation(*d.TimeZone)
+		if err != nil {
+			Log("FormatDateISO: unknown time zone '%s', using UTC. Error: %s\n", *d.TimeZone, err)
+		} else {
+			loc = l
+		}
+	}
+	dt, err := time.ParseInLocation("2006-01-02 15:04", s, loc)
+	if err != nil {
+		return d.StartDate
+	}
+	return dt.UTC().Format(time.RFC3339)
+}
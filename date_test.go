@@ -0,0 +1,39 @@
+package notionapi
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormatDateISO(t *testing.T) {
+	d := &Date{
+		Type:      DateTypeDate,
+		StartDate: "2021-03-04",
+	}
+	assert.Equal(t, "2021-03-04", FormatDateISO(d))
+
+	d = &Date{
+		Type:      DateTypeDateTime,
+		StartDate: "2021-03-04",
+		StartTime: "10:00",
+	}
+	assert.Equal(t, "2021-03-04T10:00:00Z", FormatDateISO(d))
+
+	tz := "America/Los_Angeles"
+	d.TimeZone = &tz
+	assert.Equal(t, "2021-03-04T18:00:00Z", FormatDateISO(d))
+
+	// unknown time zone is logged and UTC is used
+	var logged []string
+	LogFunc = func(format string, args ...interface{}) {
+		logged = append(logged, fmt.Sprintf(format, args...))
+	}
+	defer func() { LogFunc = nil }()
+	tz = "Mars/Olympus_Mons"
+	assert.Equal(t, "2021-03-04T10:00:00Z", FormatDateISO(d))
+	if assert.Len(t, logged, 1) {
+		assert.Contains(t, logged[0], "Mars/Olympus_Mons")
+	}
+}
@@ -112,7 +112,20 @@ func getToken() string {
 	if flgToken != "" {
 		return flgToken
 	}
-	return os.Getenv("NOTION_TOKEN")
+	return tokenFromEnv(os.Getenv)
+}
+
+// tokenFromEnv returns token from NOTION_TOKEN env variable. NOTION_TOKNE
+// (a typo we used to read) is still accepted but deprecated
+func tokenFromEnv(getenv func(string) string) string {
+	if token := strings.TrimSpace(getenv("NOTION_TOKEN")); token != "" {
+		return token
+	}
+	token := strings.TrimSpace(getenv("NOTION_TOKNE"))
+	if token != "" {
+		logf("NOTION_TOKNE env variable is deprecated, use NOTION_TOKEN\n")
+	}
+	return token
 }
 
 func exportPageToFile(id string, exportType string, recursive bool, path string) error {
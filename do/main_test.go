@@ -0,0 +1,26 @@
+package main
+
+import "testing"
+
+func TestTokenFromEnv(t *testing.T) {
+	tests := []struct {
+		env map[string]string
+		exp string
+	}{
+		{map[string]string{}, ""},
+		{map[string]string{"NOTION_TOKEN": "   "}, ""},
+		{map[string]string{"NOTION_TOKEN": " tok "}, "tok"},
+		{map[string]string{"NOTION_TOKNE": "old"}, "old"},
+		{map[string]string{"NOTION_TOKEN": "", "NOTION_TOKNE": "old"}, "old"},
+		{map[string]string{"NOTION_TOKEN": "new", "NOTION_TOKNE": "old"}, "new"},
+	}
+	for _, test := range tests {
+		getenv := func(name string) string {
+			return test.env[name]
+		}
+		got := tokenFromEnv(getenv)
+		if got != test.exp {
+			t.Errorf("tokenFromEnv() with env %v: got '%s', want '%s'", test.env, got, test.exp)
+		}
+	}
+}
@@ -4,7 +4,6 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
-	"strings"
 
 	"github.com/kjk/notionapi/caching_downloader"
 
@@ -16,13 +15,11 @@ func makeNotionClient() *notionapi.Client {
 		DebugLog:  flgVerbose,
 		AuthToken: getToken(),
 	}
-	notionToken := strings.TrimSpace(os.Getenv("NOTION_TOKNE"))
-	if notionToken == "" {
+	if client.AuthToken == "" {
 		logf("NOTION_TOKEN env variable not set. Can only access public pages\n")
 	} else {
-		logf("NOTION_TOKEN env variable set, can access private pages\n")
 		// TODO: validate that the token looks legit
-		client.AuthToken = notionToken
+		logf("NOTION_TOKEN env variable set, can access private pages\n")
 	}
 	return client
 }
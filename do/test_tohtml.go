@@ -140,7 +140,7 @@ func testToHTML(startPageID string) {
 		}
 
 		if len(expData) == 0 {
-			fmt.Printf("\n'%s' from '%s' doesn't seem correct as it's not present in referenceFiles\n", name, page.Root().Title)
+			fmt.Printf("\n'%s' from '%s' doesn't seem correct as it's not present in referenceFiles\n", name, page.Title())
 			fmt.Printf("Names in referenceFiles:\n")
 			if !didPrintRererenceFiles {
 				for s := range referenceFiles {
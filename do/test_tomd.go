@@ -182,7 +182,7 @@ func testToMarkdown(startPageID string) {
 
 		expData, ok := findReferenceMarkdownData(referenceFiles, name, pageID)
 		if !ok {
-			fmt.Printf("\n'%s' from '%s' doesn't seem correct as it's not present in referenceFiles\n", name, page.Root().Title)
+			fmt.Printf("\n'%s' from '%s' doesn't seem correct as it's not present in referenceFiles\n", name, page.Title())
 			fmt.Printf("Names in referenceFiles:\n")
 			for s := range referenceFiles {
 				fmt.Printf("  %s\n", s)
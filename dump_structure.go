@@ -2,6 +2,7 @@ package notionapi
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
 	"io"
 )
@@ -48,3 +49,36 @@ func DumpToString(page *Page) string {
 	Dump(buf, page)
 	return buf.String()
 }
+
+// DumpBlock writes type, id, title, format and types of immediate
+// children of a block to w. A debugging helper.
+func DumpBlock(w io.Writer, b *Block) {
+	wr := writer{w: w}
+	if b == nil {
+		wr.writeString("nil block\n")
+		return
+	}
+	wr.writeString(fmt.Sprintf("type: %s\n", b.Type))
+	wr.writeString(fmt.Sprintf("id: %s\n", b.ID))
+	wr.writeString(fmt.Sprintf("alive: %v\n", b.Alive))
+	title := b.PlainText()
+	if title == "" {
+		title = b.Title
+	}
+	if title != "" {
+		wr.writeString(fmt.Sprintf("title: %s\n", title))
+	}
+	if format := jsonGetMap(b.RawJSON, "format"); len(format) > 0 {
+		d, err := json.MarshalIndent(format, "", "  ")
+		if err == nil {
+			wr.writeString(fmt.Sprintf("format: %s\n", string(d)))
+		}
+	}
+	wr.writeString(fmt.Sprintf("children: %d\n", len(b.Content)))
+	wr.level++
+	for _, child := range b.Content {
+		wr.writeLevel()
+		wr.writeString(fmt.Sprintf("%s %s\n", child.Type, child.ID))
+	}
+	wr.level--
+}
@@ -0,0 +1,32 @@
+package notionapi
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDumpBlock(t *testing.T) {
+	text := newTestBlock("00000000000000000000000000000003", BlockText, "text")
+	list := newTestBlock("00000000000000000000000000000004", BlockBulletedList, "item")
+	toggle := newTestBlock("00000000000000000000000000000002", BlockToggle, "Details", text, list)
+	toggle.RawJSON = map[string]interface{}{
+		"format": map[string]interface{}{"block_color": "red"},
+	}
+	root := newTestBlock("00000000000000000000000000000001", BlockPage, "Page title", toggle)
+	page := newTestPage(root, toggle, text, list)
+
+	buf := &bytes.Buffer{}
+	DumpBlock(buf, page.BlockByID(toggle.ID))
+	s := buf.String()
+	assert.Contains(t, s, "type: toggle\n")
+	assert.Contains(t, s, "id: 00000000-0000-0000-0000-000000000002\n")
+	assert.Contains(t, s, "title: Details\n")
+	assert.Contains(t, s, `"block_color": "red"`)
+	assert.Contains(t, s, "children: 2\n")
+	assert.Contains(t, s, "  text 00000000-0000-0000-0000-000000000003\n")
+	assert.Contains(t, s, "  bulleted_list 00000000-0000-0000-0000-000000000004\n")
+	// grand-children are not included
+	assert.NotContains(t, s, "item")
+}
@@ -0,0 +1,119 @@
+package notionapi
+
+import (
+	"crypto/rand"
+	"fmt"
+)
+
+const (
+	eventDuplicateBlock = "duplicateBlock"
+)
+
+type duplicateBlockTaskRequest struct {
+	Task *duplicateBlockTask `json:"task"`
+}
+
+type duplicateBlockTask struct {
+	EventName string                 `json:"eventName"`
+	Request   *duplicateBlockRequest `json:"request"`
+}
+
+type duplicateBlockRequest struct {
+	SourceBlockID string `json:"sourceBlockId"`
+	TargetBlockID string `json:"targetBlockId"`
+	AddCopyName   bool   `json:"addCopyName"`
+}
+
+// newBlockID returns a new, random id in the format used by Notion
+func newBlockID() (string, error) {
+	var d [16]byte
+	if _, err := rand.Read(d[:]); err != nil {
+		return "", err
+	}
+	// uuid v4
+	d[6] = (d[6] & 0x0f) | 0x40
+	d[8] = (d[8] & 0x3f) | 0x80
+	return ToDashID(fmt.Sprintf("%x", d[:])), nil
+}
+
+// DuplicatePage creates a copy of a page (including its content) next to
+// the page and returns id of the copy. Copying happens on the server
+// and we wait until it's done, up to Client.TaskTimeout
+func (c *Client) DuplicatePage(pageID string) (string, error) {
+	pageID = ToDashID(pageID)
+	if !IsValidDashID(pageID) {
+		return "", fmt.Errorf("'%s' is not a valid notion id", pageID)
+	}
+	rsp, err := c.GetRecordValues([]string{pageID})
+	if err != nil {
+		return "", err
+	}
+	if len(rsp.Results) == 0 || rsp.Results[0].Value == nil {
+		return "", fmt.Errorf("page '%s' doesn't exist", pageID)
+	}
+	page := rsp.Results[0].Value
+
+	newID, err := newBlockID()
+	if err != nil {
+		return "", err
+	}
+	// target of duplication must exist before the task runs
+	ops := []*Operation{
+		{
+			ID:      newID,
+			Table:   TableBlock,
+			Path:    []string{},
+			Command: "set",
+			Args: map[string]interface{}{
+				"id":           newID,
+				"type":         BlockPage,
+				"parent_id":    page.ParentID,
+				"parent_table": page.ParentTable,
+				"alive":        true,
+				"copied_from":  pageID,
+			},
+		},
+	}
+	switch page.ParentTable {
+	case TableBlock:
+		ops = append(ops, buildListInsertOp(page.ParentID, newID, pageID))
+	case TableSpace:
+		// top-level pages are listed in space's pages
+		ops = append(ops, &Operation{
+			ID:      page.ParentID,
+			Table:   TableSpace,
+			Path:    []string{"pages"},
+			Command: "listAfter",
+			Args: map[string]interface{}{
+				"id":    newID,
+				"after": pageID,
+			},
+		})
+	}
+	if err = c.SubmitTransaction(ops); err != nil {
+		return "", err
+	}
+
+	req := &duplicateBlockTaskRequest{
+		Task: &duplicateBlockTask{
+			EventName: eventDuplicateBlock,
+			Request: &duplicateBlockRequest{
+				SourceBlockID: pageID,
+				TargetBlockID: newID,
+				AddCopyName:   true,
+			},
+		},
+	}
+	taskID, err := c.enqueueTask(req)
+	if err != nil {
+		return "", err
+	}
+	task, err := c.WaitForTask(taskID, c.getTaskTimeout())
+	if err != nil {
+		return "", err
+	}
+	if task.State != TaskStateSuccess {
+		return "", fmt.Errorf("duplicating page '%s' failed with state '%s' and error '%s'", pageID, task.State, task.Error)
+	}
+	return newID, nil
+}
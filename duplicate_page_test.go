@@ -0,0 +1,117 @@
+package notionapi
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTaskTestClient returns a client that fakes a task that is in
+// progress for the first nInProgress calls to getTasks and then
+// finishes with finalTask
+func newTaskTestClient(t *testing.T, nInProgress int, finalTask map[string]interface{}) (*Client, *[]string) {
+	return newTaskTestClientWithParent(t, TableBlock, nil, nInProgress, finalTask)
+}
+
+// newTaskTestClientWithParent is like newTaskTestClient but the page
+// is a child of parentTable and submitted operations are recorded in ops
+func newTaskTestClientWithParent(t *testing.T, parentTable string, ops *[]*Operation, nInProgress int, finalTask map[string]interface{}) (*Client, *[]string) {
+	var calls []string
+	client := newTestClient(func(r *http.Request) (*http.Response, error) {
+		calls = append(calls, r.URL.Path)
+		d, _ := ioutil.ReadAll(r.Body)
+		switch r.URL.Path {
+		case "/api/v3/getRecordValues":
+			page := &Block{
+				ID:          "00000000-0000-0000-0000-000000000002",
+				Type:        BlockPage,
+				ParentID:    "00000000-0000-0000-0000-000000000001",
+				ParentTable: parentTable,
+			}
+			return jsonResponse(map[string]interface{}{
+				"results": []interface{}{map[string]interface{}{"role": "editor", "value": page}},
+			})
+		case "/api/v3/submitTransaction":
+			if ops != nil {
+				var req submitTransactionRequest
+				require.NoError(t, json.Unmarshal(d, &req))
+				*ops = append(*ops, req.Operations...)
+			}
+		case "/api/v3/enqueueTask":
+			return jsonResponse(map[string]interface{}{"taskId": "task-1"})
+		case "/api/v3/getTasks":
+			var req getTasksRequest
+			require.NoError(t, json.Unmarshal(d, &req))
+			assert.Equal(t, []string{"task-1"}, req.TaskIDS)
+			task := finalTask
+			if nInProgress > 0 {
+				nInProgress--
+				task = map[string]interface{}{"id": "task-1", "state": "in_progress"}
+			}
+			return jsonResponse(map[string]interface{}{"results": []interface{}{task}})
+		}
+		return jsonResponse(map[string]interface{}{})
+	})
+	return client, &calls
+}
+
+func TestDuplicatePage(t *testing.T) {
+	taskPollInterval = time.Millisecond
+	defer func() { taskPollInterval = time.Second }()
+
+	client, calls := newTaskTestClient(t, 2, map[string]interface{}{"id": "task-1", "state": "success"})
+	newID, err := client.DuplicatePage("00000000000000000000000000000002")
+	require.NoError(t, err)
+	assert.True(t, IsValidDashID(newID))
+	assert.NotEqual(t, "00000000-0000-0000-0000-000000000002", newID)
+	exp := []string{
+		"/api/v3/getRecordValues",
+		"/api/v3/submitTransaction",
+		"/api/v3/enqueueTask",
+		"/api/v3/getTasks",
+		"/api/v3/getTasks",
+		"/api/v3/getTasks",
+	}
+	assert.Equal(t, exp, *calls)
+
+	client, _ = newTaskTestClient(t, 0, map[string]interface{}{"id": "task-1", "state": "failure", "error": "no access"})
+	_, err = client.DuplicatePage("00000000000000000000000000000002")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no access")
+}
+
+func TestDuplicatePageTimeout(t *testing.T) {
+	taskPollInterval = time.Millisecond
+	defer func() { taskPollInterval = time.Second }()
+
+	client, _ := newTaskTestClient(t, 1<<30, nil)
+	client.TaskTimeout = time.Millisecond * 20
+	_, err := client.DuplicatePage("00000000000000000000000000000002")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "didn't finish")
+}
+
+func TestDuplicateTopLevelPage(t *testing.T) {
+	taskPollInterval = time.Millisecond
+	defer func() { taskPollInterval = time.Second }()
+
+	var ops []*Operation
+	done := map[string]interface{}{"id": "task-1", "state": "success"}
+	client, _ := newTaskTestClientWithParent(t, TableSpace, &ops, 0, done)
+	newID, err := client.DuplicatePage("00000000000000000000000000000002")
+	require.NoError(t, err)
+	require.Len(t, ops, 2)
+	op := ops[1]
+	assert.Equal(t, "00000000-0000-0000-0000-000000000001", op.ID)
+	assert.Equal(t, TableSpace, op.Table)
+	assert.Equal(t, []string{"pages"}, op.Path)
+	assert.Equal(t, "listAfter", op.Command)
+	args := op.Args.(map[string]interface{})
+	assert.Equal(t, newID, args["id"])
+	assert.Equal(t, "00000000-0000-0000-0000-000000000002", args["after"])
+}
@@ -2,7 +2,6 @@ package notionapi
 
 import (
 	"fmt"
-	"time"
 )
 
 const (
@@ -38,34 +37,24 @@ type enqueueTaskResponse struct {
 	RawJSON map[string]interface{} `json:"-"`
 }
 
-type getTasksExportPageResponse struct {
-	Results []*exportPageResult `json:"results"`
-}
-
-type exportPageResult struct {
-	ID        string             `json:"id"`
-	EventName string             `json:"eventName"`
-	Request   *exportPageRequest `json:"request"`
-	UserID    string             `json:"userId"`
-	State     string             `json:"state"`
-	Status    *exportPageStatus  `json:"status"`
-}
-
-type exportPageStatus struct {
-	Type          string `json:"type"`
-	ExportURL     string `json:"exportURL"`
-	PagesExported int64  `json:"pagesExported"`
-}
-
 type getTasksRequest struct {
 	TaskIDS []string `json:"taskIds"`
 }
 
-// ExportPages exports a page as html or markdown, potentially recursively
-func (c *Client) ExportPages(id string, exportType string, recursive bool) ([]byte, error) {
+// ExportPage asks Notion to export a page (and its sub-pages) in a given
+// format (ExportTypeHTML or ExportTypeMarkdown) and returns the url of
+// the exported .zip file. We wait for the export up to Client.TaskTimeout
+func (c *Client) ExportPage(pageID string, format string) (string, error) {
+	return c.exportPage(pageID, format, true)
+}
+
+func (c *Client) exportPage(id string, exportType string, recursive bool) (string, error) {
 	id = ToDashID(id)
 	if !IsValidDashID(id) {
-		return nil, fmt.Errorf("'%s' is not a valid notion id", id)
+		return "", fmt.Errorf("'%s' is not a valid notion id", id)
+	}
+	if exportType != ExportTypeHTML && exportType != ExportTypeMarkdown {
+		return "", fmt.Errorf("unsupported export format '%s'", exportType)
 	}
 
 	req := &exportPageTaskRequest{
@@ -81,35 +70,29 @@ func (c *Client) ExportPages(id string, exportType string, recursive bool) ([]by
 			},
 		},
 	}
-
-	apiURL := "/api/v3/enqueueTask"
-	var rsp enqueueTaskResponse
-	var err error
-	rsp.RawJSON, err = doNotionAPI(c, apiURL, req, &rsp)
+	taskID, err := c.enqueueTask(req)
 	if err != nil {
-		return nil, err
+		return "", err
+	}
+	task, err := c.WaitForTask(taskID, c.getTaskTimeout())
+	if err != nil {
+		return "", err
+	}
+	if task.State == TaskStateFailure {
+		return "", fmt.Errorf("exporting page '%s' failed: %s", id, task.Error)
 	}
+	status := task.Status
+	if status == nil || status.Type != statusComplete || status.ExportURL == "" {
+		return "", fmt.Errorf("exporting page '%s' didn't return export url", id)
+	}
+	return status.ExportURL, nil
+}
 
-	var exportURL string
-	taskID := rsp.TaskID
-	for {
-		time.Sleep(250 * time.Millisecond)
-		req := getTasksRequest{
-			TaskIDS: []string{taskID},
-		}
-		var err error
-		var rsp getTasksExportPageResponse
-		apiURL = "/api/v3/getTasks"
-		_, err = doNotionAPI(c, apiURL, req, &rsp)
-		if err != nil {
-			return nil, err
-		}
-		status := rsp.Results[0].Status
-		if status != nil && status.Type == statusComplete {
-			exportURL = status.ExportURL
-			break
-		}
-		time.Sleep(750 * time.Millisecond)
+// ExportPages exports a page as html or markdown, potentially recursively
+func (c *Client) ExportPages(id string, exportType string, recursive bool) ([]byte, error) {
+	exportURL, err := c.exportPage(id, exportType, recursive)
+	if err != nil {
+		return nil, err
 	}
 	dlRsp, err := c.DownloadFile(exportURL)
 	if err != nil {
@@ -0,0 +1,50 @@
+package notionapi
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExportPage(t *testing.T) {
+	taskPollInterval = time.Millisecond
+	defer func() { taskPollInterval = time.Second }()
+
+	done := map[string]interface{}{
+		"id":     "task-1",
+		"state":  "success",
+		"status": map[string]interface{}{"type": "complete", "exportURL": "https://example.com/export.zip"},
+	}
+	client, calls := newTaskTestClient(t, 2, done)
+	zipURL, err := client.ExportPage("00000000000000000000000000000002", ExportTypeMarkdown)
+	require.NoError(t, err)
+	assert.Equal(t, "https://example.com/export.zip", zipURL)
+	exp := []string{
+		"/api/v3/enqueueTask",
+		"/api/v3/getTasks",
+		"/api/v3/getTasks",
+		"/api/v3/getTasks",
+	}
+	assert.Equal(t, exp, *calls)
+
+	_, err = client.ExportPage("00000000000000000000000000000002", "pdf")
+	assert.Error(t, err)
+
+	failed := map[string]interface{}{"id": "task-1", "state": "failure", "error": "no access"}
+	client, _ = newTaskTestClient(t, 0, failed)
+	_, err = client.ExportPage("00000000000000000000000000000002", ExportTypeHTML)
+	assert.Error(t, err)
+}
+
+func TestExportPageTimeout(t *testing.T) {
+	taskPollInterval = time.Millisecond
+	defer func() { taskPollInterval = time.Second }()
+
+	client, _ := newTaskTestClient(t, 1<<30, nil)
+	client.TaskTimeout = time.Millisecond * 20
+	_, err := client.ExportPage("00000000000000000000000000000002", ExportTypeHTML)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "didn't finish")
+}
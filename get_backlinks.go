@@ -0,0 +1,107 @@
+package notionapi
+
+import (
+	"fmt"
+)
+
+// /api/v3/getBacklinksForBlock request
+type getBacklinksForBlockRequest struct {
+	BlockID string `json:"blockId"`
+}
+
+type backlinkJSON struct {
+	BlockID       string `json:"block_id"`
+	MentionedFrom struct {
+		Type    string `json:"type"`
+		BlockID string `json:"block_id"`
+	} `json:"mentioned_from"`
+}
+
+type getBacklinksForBlockResponse struct {
+	Backlinks []*backlinkJSON `json:"backlinks"`
+	RecordMap *RecordMap      `json:"recordMap"`
+}
+
+// Backlink describes a block that links to (mentions) a page
+type Backlink struct {
+	// id of the block with the link
+	BlockID string
+	// id of the page that contains BlockID
+	PageID string
+	// the page that contains BlockID. Can be nil if Notion didn't
+	// return it (e.g. we don't have access to it)
+	Page *Block
+}
+
+// pageForBlock returns the page containing a block by following parents
+func pageForBlock(blocks map[string]*Block, blockID string) *Block {
+	b := blocks[blockID]
+	for b != nil {
+		if b.Type == BlockPage || b.Type == BlockCollectionViewPage {
+			return b
+		}
+		if b.ParentTable != TableBlock {
+			return nil
+		}
+		b = blocks[b.ParentID]
+	}
+	return nil
+}
+
+func backlinksFromResponse(rsp *getBacklinksForBlockResponse, rawJSON map[string]interface{}) ([]*Backlink, error) {
+	blocks := map[string]*Block{}
+	if rsp.RecordMap != nil {
+		blockByID := jsonGetMap(jsonGetMap(rawJSON, "recordMap"), "block")
+		for id, br := range rsp.RecordMap.Blocks {
+			if br == nil || br.Value == nil {
+				continue
+			}
+			b := br.Value
+			b.RawJSON = jsonGetMap(jsonGetMap(blockByID, id), "value")
+			if err := parseProperties(b); err != nil {
+				return nil, err
+			}
+			blocks[id] = b
+		}
+		for _, b := range blocks {
+			if b.ParentTable == TableBlock {
+				b.Parent = blocks[b.ParentID]
+			}
+		}
+	}
+
+	var res []*Backlink
+	for _, bl := range rsp.Backlinks {
+		blockID := bl.MentionedFrom.BlockID
+		if blockID == "" {
+			continue
+		}
+		backlink := &Backlink{
+			BlockID: blockID,
+		}
+		if page := pageForBlock(blocks, blockID); page != nil {
+			backlink.Page = page
+			backlink.PageID = page.ID
+		}
+		res = append(res, backlink)
+	}
+	return res, nil
+}
+
+// GetBacklinks returns blocks that link to a given page
+func (c *Client) GetBacklinks(pageID string) ([]*Backlink, error) {
+	id := ToDashID(pageID)
+	if !IsValidDashID(id) {
+		return nil, fmt.Errorf("'%s' is not a valid notion id", pageID)
+	}
+	req := &getBacklinksForBlockRequest{
+		BlockID: id,
+	}
+	apiURL := "/api/v3/getBacklinksForBlock"
+	var rsp getBacklinksForBlockResponse
+	rawJSON, err := doNotionAPI(c, apiURL, req, &rsp)
+	if err != nil {
+		return nil, err
+	}
+	return backlinksFromResponse(&rsp, rawJSON)
+}
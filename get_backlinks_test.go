@@ -0,0 +1,113 @@
+package notionapi
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const getBacklinksJSON1 = `{
+	"backlinks": [
+		{
+			"block_id": "00000000-0000-0000-0000-000000000001",
+			"mentioned_from": {
+				"type": "alias",
+				"block_id": "00000000-0000-0000-0000-000000000011"
+			}
+		},
+		{
+			"block_id": "00000000-0000-0000-0000-000000000001",
+			"mentioned_from": {
+				"type": "block",
+				"block_id": "00000000-0000-0000-0000-000000000021"
+			}
+		}
+	],
+	"recordMap": {
+		"block": {
+			"00000000-0000-0000-0000-000000000010": {
+				"role": "reader",
+				"value": {
+					"id": "00000000-0000-0000-0000-000000000010",
+					"type": "page",
+					"parent_id": "00000000-0000-0000-0000-0000000000ff",
+					"parent_table": "space",
+					"properties": {"title": [["First"]]}
+				}
+			},
+			"00000000-0000-0000-0000-000000000011": {
+				"role": "reader",
+				"value": {
+					"id": "00000000-0000-0000-0000-000000000011",
+					"type": "alias",
+					"parent_id": "00000000-0000-0000-0000-000000000010",
+					"parent_table": "block"
+				}
+			},
+			"00000000-0000-0000-0000-000000000020": {
+				"role": "reader",
+				"value": {
+					"id": "00000000-0000-0000-0000-000000000020",
+					"type": "page",
+					"parent_id": "00000000-0000-0000-0000-000000000010",
+					"parent_table": "block",
+					"properties": {"title": [["Second"]]}
+				}
+			},
+			"00000000-0000-0000-0000-000000000022": {
+				"role": "reader",
+				"value": {
+					"id": "00000000-0000-0000-0000-000000000022",
+					"type": "toggle",
+					"parent_id": "00000000-0000-0000-0000-000000000020",
+					"parent_table": "block"
+				}
+			},
+			"00000000-0000-0000-0000-000000000021": {
+				"role": "reader",
+				"value": {
+					"id": "00000000-0000-0000-0000-000000000021",
+					"type": "text",
+					"parent_id": "00000000-0000-0000-0000-000000000022",
+					"parent_table": "block"
+				}
+			}
+		}
+	}
+}`
+
+func TestGetBacklinks(t *testing.T) {
+	client := newTestClient(func(r *http.Request) (*http.Response, error) {
+		assert.Equal(t, "/api/v3/getBacklinksForBlock", r.URL.Path)
+		d, _ := ioutil.ReadAll(r.Body)
+		var req getBacklinksForBlockRequest
+		require.NoError(t, json.Unmarshal(d, &req))
+		assert.Equal(t, "00000000-0000-0000-0000-000000000001", req.BlockID)
+		return &http.Response{
+			StatusCode: 200,
+			Body:       ioutil.NopCloser(strings.NewReader(getBacklinksJSON1)),
+			Header:     http.Header{},
+		}, nil
+	})
+	backlinks, err := client.GetBacklinks("00000000000000000000000000000001")
+	require.NoError(t, err)
+	require.Len(t, backlinks, 2)
+
+	assert.Equal(t, "00000000-0000-0000-0000-000000000011", backlinks[0].BlockID)
+	assert.Equal(t, "00000000-0000-0000-0000-000000000010", backlinks[0].PageID)
+	assert.Equal(t, "First", backlinks[0].Page.Title)
+
+	// link nested in a toggle
+	assert.Equal(t, "00000000-0000-0000-0000-000000000021", backlinks[1].BlockID)
+	assert.Equal(t, "00000000-0000-0000-0000-000000000020", backlinks[1].PageID)
+	assert.Equal(t, "Second", backlinks[1].Page.Title)
+	assert.Equal(t, backlinks[0].Page, backlinks[1].Page.Parent)
+
+	_, err = client.GetBacklinks("not-an-id")
+	assert.Error(t, err)
+}
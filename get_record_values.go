@@ -1,6 +1,7 @@
 package notionapi
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 )
@@ -42,6 +43,39 @@ type GetRecordValuesResponse struct {
 
 // GetRecordValues executes a raw API call /api/v3/getRecordValues
 func (c *Client) GetRecordValues(ids []string) (*GetRecordValuesResponse, error) {
+	return c.getRecordValuesCtx(context.Background(), ids)
+}
+
+// getRecordValuesMaxRecords is the max number of records we ask for in a
+// single /api/v3/getRecordValues request. Notion rejects requests with
+// too many records so we split them into multiple requests
+const getRecordValuesMaxRecords = 100
+
+func (c *Client) getRecordValuesCtx(ctx context.Context, ids []string) (*GetRecordValuesResponse, error) {
+	var res *GetRecordValuesResponse
+	// always do at least one request, even for empty ids
+	for start := 0; start == 0 || start < len(ids); start += getRecordValuesMaxRecords {
+		end := start + getRecordValuesMaxRecords
+		if end > len(ids) {
+			end = len(ids)
+		}
+		rsp, err := c.getRecordValuesChunkCtx(ctx, ids[start:end])
+		if err != nil {
+			return nil, err
+		}
+		if res == nil {
+			res = rsp
+			continue
+		}
+		res.Results = append(res.Results, rsp.Results...)
+		results, _ := res.RawJSON["results"].([]interface{})
+		chunkResults, _ := rsp.RawJSON["results"].([]interface{})
+		res.RawJSON["results"] = append(results, chunkResults...)
+	}
+	return res, nil
+}
+
+func (c *Client) getRecordValuesChunkCtx(ctx context.Context, ids []string) (*GetRecordValuesResponse, error) {
 	requests := make([]RecordValueRequest, len(ids))
 
 	for pos, id := range ids {
@@ -60,7 +94,7 @@ func (c *Client) GetRecordValues(ids []string) (*GetRecordValuesResponse, error)
 	apiURL := "/api/v3/getRecordValues"
 	var rsp GetRecordValuesResponse
 	var err error
-	rsp.RawJSON, err = doNotionAPI(c, apiURL, req, &rsp)
+	rsp.RawJSON, err = doNotionAPICtx(ctx, c, apiURL, req, &rsp)
 	if err != nil {
 		return nil, err
 	}
@@ -78,7 +112,27 @@ func (c *Client) GetRecordValues(ids []string) (*GetRecordValuesResponse, error)
 	return &rsp, nil
 }
 
+// RequestRecordValues executes /api/v3/getRecordValues for records from
+// arbitrary tables. Large number of requests is split into multiple
+// API calls
 func (c *Client) RequestRecordValues(requests []RecordValueRequest) ([]ValueResponse, error) {
+	var res []ValueResponse
+	// always do at least one request, even for empty requests
+	for start := 0; start == 0 || start < len(requests); start += getRecordValuesMaxRecords {
+		end := start + getRecordValuesMaxRecords
+		if end > len(requests) {
+			end = len(requests)
+		}
+		results, err := c.requestRecordValuesChunk(requests[start:end])
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, results...)
+	}
+	return res, nil
+}
+
+func (c *Client) requestRecordValuesChunk(requests []RecordValueRequest) ([]ValueResponse, error) {
 	req := &getRecordValuesRequest{
 		Requests: requests,
 	}
@@ -94,6 +148,10 @@ func (c *Client) RequestRecordValues(requests []RecordValueRequest) ([]ValueResp
 
 	for pos := range rsp.Results {
 		rsp.Results[pos].Table = requests[pos].Table
+		// no value if we don't have access to the record
+		if len(rsp.Results[pos].Value) == 0 {
+			continue
+		}
 		var obj interface{}
 		if requests[pos].Table == TableUser {
 			rsp.Results[pos].User = &User{}
@@ -108,3 +166,68 @@ func (c *Client) RequestRecordValues(requests []RecordValueRequest) ([]ValueResp
 
 	return rsp.Results, nil
 }
+
+// GetUsers returns users with given ids, keyed by id. Users we don't
+// have access to are not included
+func (c *Client) GetUsers(userIDs []string) (map[string]*User, error) {
+	requests := make([]RecordValueRequest, len(userIDs))
+	for i, id := range userIDs {
+		requests[i] = RecordValueRequest{Table: TableUser, ID: ToDashID(id)}
+	}
+	results, err := c.RequestRecordValues(requests)
+	if err != nil {
+		return nil, err
+	}
+	res := map[string]*User{}
+	for _, v := range results {
+		u := v.User
+		if u == nil || u.ID == "" {
+			continue
+		}
+		if err := json.Unmarshal(v.Value, &u.RawJSON); err != nil {
+			return nil, err
+		}
+		res[u.ID] = u
+	}
+	return res, nil
+}
+
+func hasPublicPermission(block *Block) bool {
+	if block.Permissions == nil {
+		return false
+	}
+	for _, perm := range *block.Permissions {
+		if perm.Type == PermissionTypePublic {
+			return true
+		}
+	}
+	return false
+}
+
+// IsPagePublic returns true and public url of the page if the page is
+// shared to the web, either directly or because its parent page is
+func (c *Client) IsPagePublic(pageID string) (bool, string, error) {
+	id := ToDashID(pageID)
+	publicURL := "https://www.notion.so/" + ToNoDashID(id)
+	// sharing is inherited from parents, so we go up until we reach
+	// the top-level page
+	for depth := 0; depth < 64; depth++ {
+		rsp, err := c.GetRecordValues([]string{id})
+		if err != nil {
+			return false, "", err
+		}
+		if len(rsp.Results) == 0 || rsp.Results[0].Value == nil {
+			// we don't have access to the page or its parent
+			return false, "", nil
+		}
+		block := rsp.Results[0].Value
+		if hasPublicPermission(block) {
+			return true, publicURL, nil
+		}
+		if block.ParentTable != TableBlock || block.ParentID == "" {
+			return false, "", nil
+		}
+		id = block.ParentID
+	}
+	return false, "", nil
+}
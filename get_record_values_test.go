@@ -1,10 +1,15 @@
 package notionapi
 
 import (
+	"bytes"
 	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 const (
@@ -55,3 +60,112 @@ func TestGetRecordValues1(t *testing.T) {
 		assert.Equal(t, int64(34), v.Version)
 	}
 }
+
+func TestIsPagePublic(t *testing.T) {
+	spaceID := "00000000-0000-0000-0000-0000000000ff"
+	publicPageID := "00000000-0000-0000-0000-000000000001"
+	subPageID := "00000000-0000-0000-0000-000000000002"
+	privatePageID := "00000000-0000-0000-0000-000000000003"
+	blocks := map[string]*Block{
+		publicPageID: {
+			ID: publicPageID, Type: BlockPage, ParentID: spaceID, ParentTable: TableSpace,
+			Permissions: &[]Permission{{Role: RoleReader, Type: PermissionTypePublic}},
+		},
+		subPageID: {ID: subPageID, Type: BlockPage, ParentID: publicPageID, ParentTable: TableBlock},
+		privatePageID: {
+			ID: privatePageID, Type: BlockPage, ParentID: spaceID, ParentTable: TableSpace,
+			Permissions: &[]Permission{{Role: RoleEditor, Type: PermissionTypeUser}},
+		},
+	}
+	client := newBlocksTestClient(blocks, nil)
+
+	isPublic, uri, err := client.IsPagePublic(ToNoDashID(publicPageID))
+	assert.NoError(t, err)
+	assert.True(t, isPublic)
+	assert.Equal(t, "https://www.notion.so/00000000000000000000000000000001", uri)
+
+	// inherits sharing from the parent
+	isPublic, uri, err = client.IsPagePublic(subPageID)
+	assert.NoError(t, err)
+	assert.True(t, isPublic)
+	assert.Equal(t, "https://www.notion.so/00000000000000000000000000000002", uri)
+
+	isPublic, uri, err = client.IsPagePublic(privatePageID)
+	assert.NoError(t, err)
+	assert.False(t, isPublic)
+	assert.Equal(t, "", uri)
+}
+
+func TestGetRecordValuesChunking(t *testing.T) {
+	blocks := map[string]*Block{}
+	var ids []string
+	for i := 0; i < 250; i++ {
+		id := fmt.Sprintf("00000000-0000-0000-0000-%012d", i)
+		blocks[id] = &Block{ID: id, Type: BlockText, Alive: true}
+		ids = append(ids, id)
+	}
+	var nCalls []int
+	client := newBlocksTestClient(blocks, nil)
+	transport := client.HTTPClient.Transport
+	client.HTTPClient.Transport = roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		var req getRecordValuesRequest
+		d, _ := ioutil.ReadAll(r.Body)
+		_ = json.Unmarshal(d, &req)
+		nCalls = append(nCalls, len(req.Requests))
+		r.Body = ioutil.NopCloser(bytes.NewReader(d))
+		return transport.RoundTrip(r)
+	})
+
+	rsp, err := client.GetRecordValues(ids)
+	require.NoError(t, err)
+	assert.Equal(t, []int{100, 100, 50}, nCalls)
+	require.Len(t, rsp.Results, 250)
+	assert.Len(t, rsp.RawJSON["results"], 250)
+	for i, res := range rsp.Results {
+		assert.Equal(t, ids[i], res.Value.ID)
+		assert.Equal(t, ids[i], res.Value.RawJSON["id"])
+	}
+
+	nCalls = nil
+	var requests []RecordValueRequest
+	for _, id := range ids {
+		requests = append(requests, RecordValueRequest{Table: TableBlock, ID: id})
+	}
+	values, err := client.RequestRecordValues(requests)
+	require.NoError(t, err)
+	assert.Equal(t, []int{100, 100, 50}, nCalls)
+	assert.Len(t, values, 250)
+}
+
+func TestGetUsers(t *testing.T) {
+	user1 := "00000000-0000-0000-0000-000000000001"
+	user2 := "00000000-0000-0000-0000-000000000002"
+	missing := "00000000-0000-0000-0000-000000000003"
+	users := map[string]interface{}{
+		user1: map[string]interface{}{"id": user1, "given_name": "Jane", "family_name": "Doe", "email": "jane@example.com"},
+		user2: map[string]interface{}{"id": user2, "given_name": "John"},
+	}
+	client := newTestClient(func(r *http.Request) (*http.Response, error) {
+		var req getRecordValuesRequest
+		d, _ := ioutil.ReadAll(r.Body)
+		_ = json.Unmarshal(d, &req)
+		var results []interface{}
+		for _, rv := range req.Requests {
+			assert.Equal(t, TableUser, rv.Table)
+			if u, ok := users[rv.ID]; ok {
+				results = append(results, map[string]interface{}{"role": "reader", "value": u})
+			} else {
+				results = append(results, map[string]interface{}{"role": "none"})
+			}
+		}
+		return jsonResponse(map[string]interface{}{"results": results})
+	})
+
+	res, err := client.GetUsers([]string{ToNoDashID(user1), user2, missing})
+	require.NoError(t, err)
+	require.Len(t, res, 2)
+	assert.Equal(t, "Jane", res[user1].GivenName)
+	assert.Equal(t, "jane@example.com", res[user1].Email)
+	assert.Equal(t, "Doe", res[user1].RawJSON["family_name"])
+	assert.Equal(t, "John", res[user2].GivenName)
+}
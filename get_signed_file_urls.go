@@ -49,6 +49,55 @@ func (c *Client) GetSignedFileUrls(urls []string) (*GetSignedFileUrlsResponse, e
 	return &rsp, nil
 }
 
+// FileRef describes a file stored in Notion and referenced by a block
+type FileRef struct {
+	BlockID string
+	FileID  string
+	// Source is the url of the file as stored in the block
+	Source string
+	// SignedURL is a temporary url from which the file can be downloaded
+	SignedURL string
+}
+
+// ResolvePageFiles returns files stored in Notion (e.g. images or
+// file attachments) referenced by blocks of the page, with signed urls
+// for downloading them
+func (c *Client) ResolvePageFiles(page *Page) ([]*FileRef, error) {
+	var res []*FileRef
+	var urls []string
+	urlToIdx := map[string]int{}
+	page.ForEachBlock(func(block *Block) {
+		if block.Source == "" {
+			return
+		}
+		for _, fileID := range block.FileIDs {
+			res = append(res, &FileRef{
+				BlockID: block.ID,
+				FileID:  fileID,
+				Source:  block.Source,
+			})
+		}
+		if _, ok := urlToIdx[block.Source]; !ok && len(block.FileIDs) > 0 {
+			urlToIdx[block.Source] = len(urls)
+			urls = append(urls, block.Source)
+		}
+	})
+	if len(urls) == 0 {
+		return res, nil
+	}
+	rsp, err := c.GetSignedFileUrls(urls)
+	if err != nil {
+		return nil, err
+	}
+	if len(rsp.SignedUrls) != len(urls) {
+		return nil, fmt.Errorf("asked for %d signed urls, got %d", len(urls), len(rsp.SignedUrls))
+	}
+	for _, ref := range res {
+		ref.SignedURL = rsp.SignedUrls[urlToIdx[ref.Source]]
+	}
+	return res, nil
+}
+
 // DownloadFileResponse is a result of DownloadFile()
 type DownloadFileResponse struct {
 	URL           string
@@ -114,8 +163,8 @@ func (c *Client) DownloadFile(uri string) (*DownloadFileResponse, error) {
 	if err != nil {
 		return nil, err
 	}
-	if c.AuthToken != "" {
-		req.Header.Set("cookie", fmt.Sprintf("token_v2=%v", c.AuthToken))
+	if err = c.setAuthCookie(req); err != nil {
+		return nil, err
 	}
 	httpClient := c.getHTTPClient()
 	resp, err := httpClient.Do(req)
@@ -0,0 +1,50 @@
+package notionapi
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolvePageFiles(t *testing.T) {
+	imgURL := s3URLPrefix + "1111/image.png"
+	fileURL := s3URLPrefix + "2222/report.pdf"
+	image := newTestBlock("00000000000000000000000000000002", BlockImage, "")
+	image.Source = imgURL
+	image.FileIDs = []string{"1111"}
+	file := newTestBlock("00000000000000000000000000000003", BlockFile, "report.pdf")
+	file.Source = fileURL
+	file.FileIDs = []string{"2222"}
+	// external images are not stored in Notion
+	external := newTestBlock("00000000000000000000000000000004", BlockImage, "")
+	external.Source = "https://example.com/x.png"
+	root := newTestBlock("00000000000000000000000000000001", BlockPage, "Page", image, file, external)
+	page := newTestPage(root, image, file, external)
+
+	var nCalls int
+	client := newTestClient(func(r *http.Request) (*http.Response, error) {
+		nCalls++
+		assert.Equal(t, "/api/v3/getSignedFileUrls", r.URL.Path)
+		var req getSignedFileUrlsRequest
+		d, _ := ioutil.ReadAll(r.Body)
+		require.NoError(t, json.Unmarshal(d, &req))
+		var signed []string
+		for _, u := range req.Urls {
+			signed = append(signed, u.URL+"?signature=x")
+		}
+		return jsonResponse(map[string]interface{}{"signedUrls": signed})
+	})
+
+	refs, err := client.ResolvePageFiles(page)
+	require.NoError(t, err)
+	assert.Equal(t, 1, nCalls)
+	exp := []*FileRef{
+		{BlockID: image.ID, FileID: "1111", Source: imgURL, SignedURL: imgURL + "?signature=x"},
+		{BlockID: file.ID, FileID: "2222", Source: fileURL, SignedURL: fileURL + "?signature=x"},
+	}
+	assert.Equal(t, exp, refs)
+}
@@ -0,0 +1,113 @@
+package notionapi
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	// TaskStateInProgress is Task.State of a task that is not finished yet
+	TaskStateInProgress = "in_progress"
+	// TaskStateSuccess is Task.State of a task that finished successfully
+	TaskStateSuccess = "success"
+	// TaskStateFailure is Task.State of a task that failed
+	TaskStateFailure = "failure"
+)
+
+// DefaultTaskTimeout is how long DuplicatePage and ExportPage wait for
+// the server to finish a task if Client.TaskTimeout is not set
+const DefaultTaskTimeout = time.Minute * 10
+
+// how often we check if async task is finished. A variable so that
+// tests don't have to wait
+var taskPollInterval = time.Second
+
+// Task describes an asynchronous task executed on Notion server, like
+// exporting or duplicating a page
+type Task struct {
+	ID        string      `json:"id"`
+	EventName string      `json:"eventName"`
+	State     string      `json:"state"`
+	Status    *TaskStatus `json:"status,omitempty"`
+	// set if State is TaskStateFailure
+	Error string `json:"error,omitempty"`
+
+	RawJSON map[string]interface{} `json:"-"`
+}
+
+// TaskStatus describes progress or result of a Task
+type TaskStatus struct {
+	// "progress" or "complete"
+	Type          string `json:"type"`
+	PagesExported int64  `json:"pagesExported,omitempty"`
+	// for exportBlock tasks, url of the exported .zip file
+	ExportURL string `json:"exportURL,omitempty"`
+}
+
+// IsDone returns true if task is no longer in progress
+func (t *Task) IsDone() bool {
+	return t.State != TaskStateInProgress && t.State != ""
+}
+
+type getTasksResponse struct {
+	Results []*Task `json:"results"`
+}
+
+func (c *Client) enqueueTask(req interface{}) (string, error) {
+	apiURL := "/api/v3/enqueueTask"
+	var rsp enqueueTaskResponse
+	var err error
+	rsp.RawJSON, err = doNotionAPI(c, apiURL, req, &rsp)
+	if err != nil {
+		return "", err
+	}
+	if rsp.TaskID == "" {
+		return "", fmt.Errorf("enqueueTask didn't return task id")
+	}
+	return rsp.TaskID, nil
+}
+
+// GetTasks executes a raw API call /api/v3/getTasks and returns
+// current state of tasks
+func (c *Client) GetTasks(taskIDs []string) ([]*Task, error) {
+	req := getTasksRequest{
+		TaskIDS: taskIDs,
+	}
+	var rsp getTasksResponse
+	apiURL := "/api/v3/getTasks"
+	rawJSON, err := doNotionAPI(c, apiURL, req, &rsp)
+	if err != nil {
+		return nil, err
+	}
+	resultsJSON, _ := rawJSON["results"].([]interface{})
+	for i, task := range rsp.Results {
+		if i < len(resultsJSON) && task != nil {
+			task.RawJSON, _ = resultsJSON[i].(map[string]interface{})
+		}
+	}
+	return rsp.Results, nil
+}
+
+// WaitForTask polls the state of a task until it's finished and returns
+// it. If timeout is > 0 and the task doesn't finish in that time, it
+// returns an error
+func (c *Client) WaitForTask(taskID string, timeout time.Duration) (*Task, error) {
+	start := time.Now()
+	for {
+		tasks, err := c.GetTasks([]string{taskID})
+		if err != nil {
+			return nil, err
+		}
+		if len(tasks) == 0 || tasks[0] == nil {
+			return nil, fmt.Errorf("task '%s' doesn't exist", taskID)
+		}
+		task := tasks[0]
+		if task.IsDone() {
+			return task, nil
+		}
+		if timeout > 0 && time.Since(start) > timeout {
+			return nil, fmt.Errorf("task '%s' didn't finish in %s", taskID, timeout)
+		}
+		time.Sleep(taskPollInterval)
+	}
+}
@@ -0,0 +1,57 @@
+package notionapi
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetTasks(t *testing.T) {
+	inProgress := map[string]interface{}{
+		"id":        "task-1",
+		"eventName": "exportBlock",
+		"state":     "in_progress",
+		"status":    map[string]interface{}{"type": "progress", "pagesExported": 3},
+	}
+	client, _ := newTaskTestClient(t, 0, inProgress)
+	tasks, err := client.GetTasks([]string{"task-1"})
+	require.NoError(t, err)
+	require.Len(t, tasks, 1)
+	task := tasks[0]
+	assert.False(t, task.IsDone())
+	assert.Equal(t, "exportBlock", task.EventName)
+	assert.Equal(t, "progress", task.Status.Type)
+	assert.Equal(t, int64(3), task.Status.PagesExported)
+	assert.Equal(t, "task-1", task.RawJSON["id"])
+
+	complete := map[string]interface{}{
+		"id":     "task-1",
+		"state":  "success",
+		"status": map[string]interface{}{"type": "complete", "exportURL": "https://example.com/export.zip"},
+	}
+	client, _ = newTaskTestClient(t, 0, complete)
+	tasks, err = client.GetTasks([]string{"task-1"})
+	require.NoError(t, err)
+	assert.True(t, tasks[0].IsDone())
+	assert.Equal(t, TaskStateSuccess, tasks[0].State)
+	assert.Equal(t, "https://example.com/export.zip", tasks[0].Status.ExportURL)
+}
+
+func TestWaitForTask(t *testing.T) {
+	taskPollInterval = time.Millisecond
+	defer func() { taskPollInterval = time.Second }()
+
+	done := map[string]interface{}{"id": "task-1", "state": "success"}
+	client, calls := newTaskTestClient(t, 3, done)
+	task, err := client.WaitForTask("task-1", 0)
+	require.NoError(t, err)
+	assert.Equal(t, TaskStateSuccess, task.State)
+	assert.Len(t, *calls, 4)
+
+	// never finishes in time
+	client, _ = newTaskTestClient(t, 1000000, done)
+	_, err = client.WaitForTask("task-1", 5*time.Millisecond)
+	assert.Error(t, err)
+}
@@ -31,8 +31,28 @@ const (
 	AttrDate = "d"
 	// AtttrPage represents a link to a Notion page
 	AttrPage = "p"
+	// AttrLinkMention represents a rich mention (link preview) of
+	// an external url
+	AttrLinkMention = "lm"
+	// AttrExternalObject represents a mention of an external object
+	// instance. The data lives in a separate record that we don't load
+	AttrExternalObject = "eoi"
+	// AttrEquation represents an inline equation. The text of the span
+	// is a placeholder, the equation is the second value
+	AttrEquation = "e"
 )
 
+// LinkMention describes AttrLinkMention
+type LinkMention struct {
+	Href         string `json:"href"`
+	Title        string `json:"title,omitempty"`
+	Description  string `json:"description,omitempty"`
+	IconURL      string `json:"icon_url,omitempty"`
+	LinkAuthor   string `json:"link_author,omitempty"`
+	LinkProvider string `json:"link_provider,omitempty"`
+	ThumbnailURL string `json:"thumbnail_url,omitempty"`
+}
+
 // TextAttr describes attributes of a span of text
 // First element is name of the attribute (e.g. AttrLink)
 // The rest are optional information about attribute (e.g.
@@ -89,6 +109,11 @@ func AttrGetHighlight(attr TextAttr) string {
 	return attr[1]
 }
 
+func AttrGetEquation(attr TextAttr) string {
+	panicIfAttrNot(attr, "AttrGetEquation", AttrEquation)
+	return attr[1]
+}
+
 func AttrGetDate(attr TextAttr) *Date {
 	panicIfAttrNot(attr, "AttrGetDate", AttrDate)
 	js := []byte(attr[1])
@@ -100,6 +125,17 @@ func AttrGetDate(attr TextAttr) *Date {
 	return d
 }
 
+func AttrGetLinkMention(attr TextAttr) *LinkMention {
+	panicIfAttrNot(attr, "AttrGetLinkMention", AttrLinkMention)
+	js := []byte(attr[1])
+	var lm *LinkMention
+	err := json.Unmarshal(js, &lm)
+	if err != nil {
+		panic(err.Error())
+	}
+	return lm
+}
+
 func parseTextSpanAttribute(b *TextSpan, a []interface{}) error {
 	if len(a) == 0 {
 		return fmt.Errorf("attribute array is empty")
@@ -109,10 +145,10 @@ func parseTextSpanAttribute(b *TextSpan, a []interface{}) error {
 		return fmt.Errorf("a[0] is not string. a[0] is of type %T and value %#v", a[0], a)
 	}
 	attr := TextAttr{s}
-	if s == AttrDate {
-		// date is a special case in that second value is
+	if s == AttrDate || s == AttrLinkMention {
+		// date and link mention are a special case in that second value is
 		if len(a) != 2 {
-			return fmt.Errorf("unexpected date attribute. Expected 2 values, got: %#v", a)
+			return fmt.Errorf("unexpected %s attribute. Expected 2 values, got: %#v", s, a)
 		}
 		v, ok := a[1].(map[string]interface{})
 		if !ok {
@@ -187,31 +223,43 @@ func parseTextSpan(a []interface{}) (*TextSpan, error) {
 	return res, nil
 }
 
-// ParseTextSpans parses content from JSON into an easier to use form
+// ParseTextSpans parses content from JSON into an easier to use form.
+// Malformed spans are skipped (and logged with Log). Only returns an
+// error if raw is not an array
 func ParseTextSpans(raw interface{}) ([]*TextSpan, error) {
+	res, warnings, err := ParseTextSpansWithWarnings(raw)
+	for _, w := range warnings {
+		Log("ParseTextSpans: %s\n", w)
+	}
+	return res, err
+}
+
+// ParseTextSpansWithWarnings is like ParseTextSpans but returns
+// a description of every malformed span that was skipped
+func ParseTextSpansWithWarnings(raw interface{}) ([]*TextSpan, []string, error) {
 	if raw == nil {
-		return nil, nil
+		return nil, nil, nil
 	}
-	var res []*TextSpan
 	a, ok := raw.([]interface{})
 	if !ok {
-		return nil, fmt.Errorf("raw is not of []interface{}. raw type: %T, value: '%#v'", raw, raw)
+		return nil, nil, fmt.Errorf("raw is not of []interface{}. raw type: %T, value: '%#v'", raw, raw)
 	}
-	if len(a) == 0 {
-		return nil, fmt.Errorf("raw is empty")
-	}
-	for _, v := range a {
+	res := []*TextSpan{}
+	var warnings []string
+	for i, v := range a {
 		a2, ok := v.([]interface{})
 		if !ok {
-			return nil, fmt.Errorf("v is not []interface{}. v type: %T, value: '%#v'", v, v)
+			warnings = append(warnings, fmt.Sprintf("span %d is not []interface{}. type: %T, value: '%#v'", i, v, v))
+			continue
 		}
 		span, err := parseTextSpan(a2)
 		if err != nil {
-			return nil, err
+			warnings = append(warnings, fmt.Sprintf("span %d: %s", i, err))
+			continue
 		}
 		res = append(res, span)
 	}
-	return res, nil
+	return res, warnings, nil
 }
 
 // TextSpansToString returns flattened content of inline blocks, without formatting
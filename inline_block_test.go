@@ -361,3 +361,65 @@ func TestParseTextSpan7(t *testing.T) {
 	blocks := parseTextSpans(t, title7)
 	assert.Equal(t, 4, len(blocks))
 }
+
+const titleLinkMention = `{
+	"title": [
+		[
+			"‣",
+			[
+				[
+					"lm",
+					{
+						"href": "https://github.com/kjk/notionapi",
+						"title": "kjk/notionapi",
+						"icon_url": "https://github.com/favicon.ico"
+					}
+				]
+			]
+		]
+	]
+}`
+
+func TestParseTextSpansLinkMention(t *testing.T) {
+	blocks := parseTextSpans(t, titleLinkMention)
+	assert.Equal(t, 1, len(blocks))
+	attr := blocks[0].Attrs[0]
+	assert.Equal(t, AttrLinkMention, AttrGetType(attr))
+	lm := AttrGetLinkMention(attr)
+	assert.Equal(t, "https://github.com/kjk/notionapi", lm.Href)
+	assert.Equal(t, "kjk/notionapi", lm.Title)
+	assert.Equal(t, "https://github.com/favicon.ico", lm.IconURL)
+}
+
+func TestParseTextSpansTolerant(t *testing.T) {
+	spans, err := ParseTextSpans(nil)
+	assert.NoError(t, err)
+	assert.Len(t, spans, 0)
+
+	spans, err = ParseTextSpans([]interface{}{})
+	assert.NoError(t, err)
+	assert.Len(t, spans, 0)
+
+	s := `[
+		["hello "],
+		"not an array",
+		[],
+		["bold", [["b"]]],
+		["bad attr", [[1]]],
+		[" world"]
+	]`
+	var raw interface{}
+	assert.NoError(t, json.Unmarshal([]byte(s), &raw))
+	spans, warnings, err := ParseTextSpansWithWarnings(raw)
+	assert.NoError(t, err)
+	assert.Len(t, warnings, 3)
+	assert.Equal(t, "hello bold world", TextSpansToString(spans))
+	assert.Equal(t, AttrBold, AttrGetType(spans[1].Attrs[0]))
+
+	spans, err = ParseTextSpans(raw)
+	assert.NoError(t, err)
+	assert.Len(t, spans, 3)
+
+	_, err = ParseTextSpans("not an array")
+	assert.Error(t, err)
+}
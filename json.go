@@ -1,6 +1,10 @@
 package notionapi
 
-import "encoding/json"
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
 
 func jsonUnmarshalFromMap(m map[string]interface{}, v interface{}) error {
 	d, err := json.Marshal(m)
@@ -27,3 +31,100 @@ func jsonGetArray(m map[string]interface{}, key string) []interface{} {
 	}
 	return nil
 }
+
+func jsonExpectDelim(dec *json.Decoder, expected json.Delim) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if d, ok := tok.(json.Delim); !ok || d != expected {
+		return fmt.Errorf("expected '%s', got %v", expected, tok)
+	}
+	return nil
+}
+
+// jsonReadKey reads a key of an object. Returns false if reached the end
+// of the object
+func jsonReadKey(dec *json.Decoder) (string, bool, error) {
+	if !dec.More() {
+		// consume closing '}'
+		_, err := dec.Token()
+		return "", false, err
+	}
+	tok, err := dec.Token()
+	if err != nil {
+		return "", false, err
+	}
+	key, ok := tok.(string)
+	if !ok {
+		return "", false, fmt.Errorf("expected object key, got %v", tok)
+	}
+	return key, true, nil
+}
+
+func decodeRecordMapTables(dec *json.Decoder, cb func(table string, id string, raw json.RawMessage)) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if tok == nil {
+		// "recordMap": null
+		return nil
+	}
+	if d, ok := tok.(json.Delim); !ok || d != '{' {
+		return fmt.Errorf("expected '{', got %v", tok)
+	}
+	for {
+		table, ok, err := jsonReadKey(dec)
+		if err != nil || !ok {
+			return err
+		}
+		if err = jsonExpectDelim(dec, '{'); err != nil {
+			return err
+		}
+		for {
+			id, ok, err := jsonReadKey(dec)
+			if err != nil {
+				return err
+			}
+			if !ok {
+				break
+			}
+			var raw json.RawMessage
+			if err = dec.Decode(&raw); err != nil {
+				return err
+			}
+			cb(table, id, raw)
+		}
+	}
+}
+
+// DecodeRecordMap reads JSON response of Notion API (like the one
+// returned by /api/v3/loadUserContent) from r and calls cb for every
+// record in its "recordMap". raw is JSON of the record i.e.
+// { "role": ..., "value": ... }.
+// Unlike json.Unmarshal it doesn't read the whole response in memory,
+// which matters for large workspaces
+func DecodeRecordMap(r io.Reader, cb func(table string, id string, raw json.RawMessage)) error {
+	dec := json.NewDecoder(r)
+	if err := jsonExpectDelim(dec, '{'); err != nil {
+		return err
+	}
+	for {
+		key, ok, err := jsonReadKey(dec)
+		if err != nil || !ok {
+			return err
+		}
+		if key == "recordMap" {
+			if err = decodeRecordMapTables(dec, cb); err != nil {
+				return err
+			}
+			continue
+		}
+		// skip the value
+		var v json.RawMessage
+		if err = dec.Decode(&v); err != nil {
+			return err
+		}
+	}
+}
@@ -1,5 +1,7 @@
 package notionapi
 
+import "context"
+
 // /api/v3/loadPageChunk request
 type loadPageChunkRequest struct {
 	PageID          string `json:"pageId"`
@@ -90,6 +92,7 @@ type CollectionView struct {
 	ParentID    string                `json:"parent_id"`
 	ParentTable string                `json:"parent_table"`
 	Query       *CollectionViewQuery  `json:"query"`
+	Query2      *CollectionViewQuery2 `json:"query2"`
 	Type        string                `json:"type"`
 	Version     int                   `json:"version"`
 
@@ -100,6 +103,30 @@ type CollectionView struct {
 type CollectionViewFormat struct {
 	TableProperties []*TableProperty `json:"table_properties"`
 	TableWrap       bool             `json:"table_wrap"`
+	// if set, rows are grouped by values of a property
+	CollectionGroupBy *CollectionGroupBy `json:"collection_group_by"`
+	// order and visibility of groups
+	CollectionGroups []*CollectionGroup `json:"collection_groups"`
+}
+
+// CollectionGroupBy describes which property a collection view is grouped by
+type CollectionGroupBy struct {
+	Type     string `json:"type"`
+	Property string `json:"property"`
+}
+
+// CollectionGroup describes a single group of a grouped collection view
+type CollectionGroup struct {
+	Property string                `json:"property"`
+	Hidden   bool                  `json:"hidden"`
+	Value    *CollectionGroupValue `json:"value"`
+}
+
+// CollectionGroupValue is a value of a property rows in a group have.
+// Value is nil for a group of rows without a value
+type CollectionGroupValue struct {
+	Type  string      `json:"type"`
+	Value interface{} `json:"value"`
 }
 
 // CollectionViewQuery describes a query
@@ -107,6 +134,25 @@ type CollectionViewQuery struct {
 	Aggregate []*AggregateQuery `json:"aggregate"`
 }
 
+// CollectionViewQuery2 is a newer version of a query of a collection view
+type CollectionViewQuery2 struct {
+	// id of a property rows are grouped by. Views that set
+	// format.collection_group_by don't have it
+	GroupBy string `json:"group_by"`
+}
+
+// GroupByProperty returns id of a property rows of the view are grouped
+// by or empty string if the view is not grouped
+func (v *CollectionView) GroupByProperty() string {
+	if v.Format != nil && v.Format.CollectionGroupBy != nil && v.Format.CollectionGroupBy.Property != "" {
+		return v.Format.CollectionGroupBy.Property
+	}
+	if v.Query2 != nil {
+		return v.Query2.GroupBy
+	}
+	return ""
+}
+
 // AggregateQuery describes an aggregate query
 type AggregateQuery struct {
 	AggregationType string `json:"aggregation_type"`
@@ -141,14 +187,34 @@ type Collection struct {
 	RawJSON map[string]interface{} `json:"-"`
 }
 
-func (c *Collection) Name() string {
+// DefaultCollectionName is returned by Collection.Name() for collections
+// without a name
+var DefaultCollectionName = "Untitled Database"
+
+// NameSpans returns name of the collection as text spans (which can have
+// formatting). Returns nil if the collection has no name
+func (c *Collection) NameSpans() []*TextSpan {
 	if len(c.name) == 0 {
-		name := jsonGetArray(c.RawJSON, "name")
-		if name != nil {
+		switch name := c.RawJSON["name"].(type) {
+		case []interface{}:
 			c.name, _ = ParseTextSpans(name)
+		case string:
+			if name != "" {
+				c.name = []*TextSpan{{Text: name}}
+			}
 		}
 	}
-	return TextSpansToString(c.name)
+	return c.name
+}
+
+// Name returns name of the collection as plain text or
+// DefaultCollectionName if the collection has no name
+func (c *Collection) Name() string {
+	name := TextSpansToString(c.NameSpans())
+	if name == "" {
+		return DefaultCollectionName
+	}
+	return name
 }
 
 // CollectionFormat describes format of a collection
@@ -229,7 +295,12 @@ type Reminder struct {
 }
 
 // LoadPageChunk executes a raw API call /api/v3/loadPageChunk
-func (c *Client) LoadPageChunk(pageID string, chunkNo int, cur *cursor) (*LoadPageChunkResponse, error) { // emulating notion's website api usage: 50 items on first request,
+func (c *Client) LoadPageChunk(pageID string, chunkNo int, cur *cursor) (*LoadPageChunkResponse, error) {
+	return c.loadPageChunkCtx(context.Background(), pageID, chunkNo, cur)
+}
+
+func (c *Client) loadPageChunkCtx(ctx context.Context, pageID string, chunkNo int, cur *cursor) (*LoadPageChunkResponse, error) {
+	// emulating notion's website api usage: 50 items on first request,
 	// 30 on subsequent requests
 	limit := 30
 	apiURL := "/api/v3/loadPageChunk"
@@ -249,7 +320,7 @@ func (c *Client) LoadPageChunk(pageID string, chunkNo int, cur *cursor) (*LoadPa
 	}
 	var rsp LoadPageChunkResponse
 	var err error
-	rsp.RawJSON, err = doNotionAPI(c, apiURL, req, &rsp)
+	rsp.RawJSON, err = doNotionAPICtx(ctx, c, apiURL, req, &rsp)
 	if err != nil {
 		return nil, err
 	}
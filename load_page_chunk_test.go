@@ -151,3 +151,29 @@ func TestLoadPageChunk1(t *testing.T) {
 
 	}
 }
+
+func TestCollectionName(t *testing.T) {
+	col := &Collection{RawJSON: map[string]interface{}{"name": "Plain"}}
+	assert.Equal(t, "Plain", col.Name())
+	assert.Equal(t, []*TextSpan{{Text: "Plain"}}, col.NameSpans())
+
+	col = &Collection{RawJSON: map[string]interface{}{
+		"name": []interface{}{
+			[]interface{}{"Bold", []interface{}{[]interface{}{"b"}}},
+			[]interface{}{" tasks"},
+		},
+	}}
+	assert.Equal(t, "Bold tasks", col.Name())
+	spans := col.NameSpans()
+	if assert.Len(t, spans, 2) {
+		assert.Equal(t, "Bold", spans[0].Text)
+		assert.Equal(t, AttrBold, spans[0].Attrs[0][0])
+	}
+
+	col = &Collection{RawJSON: map[string]interface{}{}}
+	assert.Nil(t, col.NameSpans())
+	assert.Equal(t, "Untitled Database", col.Name())
+	defer func(s string) { DefaultCollectionName = s }(DefaultCollectionName)
+	DefaultCollectionName = "Unnamed"
+	assert.Equal(t, "Unnamed", col.Name())
+}
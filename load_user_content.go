@@ -1,47 +1,213 @@
 package notionapi
 
 import (
+	"context"
 	"encoding/json"
+	"io"
+	"sort"
 )
 
-func (c *Client) LoadUserContent() (*ValueResponse, error) {
+const (
+	// SpaceRoleOwner is a role of a user who administers a space
+	SpaceRoleOwner = "owner"
+	// SpaceRoleMember is a role of a regular member of a space
+	SpaceRoleMember = "member"
+	// SpaceRoleGuest is a role of a user who only has access to
+	// some pages in a space
+	SpaceRoleGuest = "guest"
+)
+
+// SpaceView describes a user's view of a space. There is one for
+// every space the user has access to, including spaces where
+// the user is only a guest
+type SpaceView struct {
+	ID              string   `json:"id"`
+	Version         int      `json:"version"`
+	Alive           bool     `json:"alive"`
+	SpaceID         string   `json:"space_id"`
+	ParentID        string   `json:"parent_id"`
+	ParentTable     string   `json:"parent_table"`
+	BookmarkedPages []string `json:"bookmarked_pages,omitempty"`
+}
+
+// userContentRecordMap is recordMap returned by /api/v3/loadUserContent
+type userContentRecordMap = map[string]map[string]ValueResponse
 
+func (c *Client) loadUserContentRecordMap() (userContentRecordMap, error) {
 	req := struct{}{}
 
 	apiURL := "/api/v3/loadUserContent"
 	var rsp struct {
-		RecordMap map[string]map[string]ValueResponse `json:"recordMap"`
+		RecordMap userContentRecordMap `json:"recordMap"`
 	}
 	var err error
 	if _, err = doNotionAPI(c, apiURL, req, &rsp); err != nil {
 		return nil, err
 	}
+	return rsp.RecordMap, nil
+}
+
+// UserContent is the content returned by /api/v3/loadUserContent.
+// Records are sorted by id
+type UserContent struct {
+	Users  []*User
+	Blocks []*Block
+	Spaces []*Space
+}
+
+// LoadUserContent returns the authenticated user, their top-level
+// pages and spaces
+func (c *Client) LoadUserContent() (*UserContent, error) {
+	recordMap, err := c.loadUserContentRecordMap()
+	if err != nil {
+		return nil, err
+	}
+	return userContentFromRecordMap(recordMap)
+}
+
+// sortedRecordIDs returns ids of records of a table, sorted so that
+// the result doesn't depend on the order of iterating a map
+func sortedRecordIDs(records map[string]ValueResponse) []string {
+	var ids []string
+	for id := range records {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+func userContentFromRecordMap(recordMap userContentRecordMap) (*UserContent, error) {
+	res := &UserContent{}
+	for _, id := range sortedRecordIDs(recordMap[TableUser]) {
+		var user User
+		v := recordMap[TableUser][id].Value
+		if err := json.Unmarshal(v, &user); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(v, &user.RawJSON); err != nil {
+			return nil, err
+		}
+		res.Users = append(res.Users, &user)
+	}
+	for _, id := range sortedRecordIDs(recordMap[TableBlock]) {
+		var block Block
+		v := recordMap[TableBlock][id].Value
+		if err := json.Unmarshal(v, &block); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(v, &block.RawJSON); err != nil {
+			return nil, err
+		}
+		if err := parseProperties(&block); err != nil {
+			return nil, err
+		}
+		res.Blocks = append(res.Blocks, &block)
+	}
+	for _, id := range sortedRecordIDs(recordMap[TableSpace]) {
+		var space Space
+		v := recordMap[TableSpace][id].Value
+		if err := json.Unmarshal(v, &space); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(v, &space.RawJSON); err != nil {
+			return nil, err
+		}
+		res.Spaces = append(res.Spaces, &space)
+	}
+	return res, nil
+}
+
+// LoadUserContentStreaming is like LoadUserContent but calls cb for every
+// record in the response as it's being read (see DecodeRecordMap) instead
+// of decoding the whole response in memory
+func (c *Client) LoadUserContentStreaming(cb func(table string, id string, raw json.RawMessage)) error {
+	req := struct{}{}
+	apiURL := "/api/v3/loadUserContent"
+	return doNotionAPIStreamCtx(context.Background(), c, apiURL, req, func(r io.Reader) error {
+		return DecodeRecordMap(r, cb)
+	})
+}
+
+// GetSpaceRoles returns the role (SpaceRoleOwner, SpaceRoleMember or
+// SpaceRoleGuest) of the authenticated user in each space they
+// have access to, keyed by space id
+func (c *Client) GetSpaceRoles() (map[string]string, error) {
+	recordMap, err := c.loadUserContentRecordMap()
+	if err != nil {
+		return nil, err
+	}
+	return spaceRolesFromRecordMap(recordMap)
+}
+
+// space permission roles are "editor" for admins and e.g. "read_and_write"
+// for regular members. A user without a permission entry in a space
+// they can see is a guest
+func spaceRoleFromPermissions(space *Space, userID string) string {
+	for _, perm := range space.Permissions {
+		if perm.Type != PermissionTypeUser || perm.UserID != userID {
+			continue
+		}
+		if perm.Role == RoleEditor {
+			return SpaceRoleOwner
+		}
+		return SpaceRoleMember
+	}
+	return SpaceRoleGuest
+}
+
+// activeUserID returns id of the authenticated user. The record map can
+// have other users (e.g. members of shared spaces) but space views of
+// the authenticated user have it as a parent
+func activeUserID(recordMap userContentRecordMap) (string, error) {
+	views := recordMap[TableSpaceView]
+	for _, id := range sortedRecordIDs(views) {
+		var spaceView SpaceView
+		if err := json.Unmarshal(views[id].Value, &spaceView); err != nil {
+			return "", err
+		}
+		if spaceView.ParentTable == "user_root" && spaceView.ParentID != "" {
+			return spaceView.ParentID, nil
+		}
+	}
+	userIDs := sortedRecordIDs(recordMap[TableUser])
+	if len(userIDs) == 0 {
+		return "", nil
+	}
+	return userIDs[0], nil
+}
+
+func spaceRolesFromRecordMap(recordMap userContentRecordMap) (map[string]string, error) {
+	userID, err := activeUserID(recordMap)
+	if err != nil {
+		return nil, err
+	}
+
+	idToSpace := map[string]*Space{}
+	for id, v := range recordMap[TableSpace] {
+		var space Space
+		if err := json.Unmarshal(v.Value, &space); err != nil {
+			return nil, err
+		}
+		idToSpace[id] = &space
+	}
 
-	result := &ValueResponse{}
-
-	for table, values := range rsp.RecordMap {
-		for _, value := range values {
-			var obj interface{}
-			if table == TableUser {
-				result.User = &User{}
-				obj = result.User
-			}
-			if table == TableBlock {
-				result.Block = &Block{}
-				obj = result.Block
-			}
-			if table == TableSpace {
-				result.Space = &Space{}
-				obj = result.Space
-			}
-			if obj == nil {
-				continue
-			}
-			if err := json.Unmarshal(value.Value, &obj); err != nil {
-				return nil, err
-			}
-		}
-	}
-
-	return result, nil
+	res := map[string]string{}
+	for id, space := range idToSpace {
+		res[id] = spaceRoleFromPermissions(space, userID)
+	}
+	// guests don't always get the space record, only space_view
+	for _, v := range recordMap[TableSpaceView] {
+		var spaceView SpaceView
+		if err := json.Unmarshal(v.Value, &spaceView); err != nil {
+			return nil, err
+		}
+		if !spaceView.Alive || spaceView.SpaceID == "" {
+			continue
+		}
+		if _, ok := res[spaceView.SpaceID]; ok {
+			continue
+		}
+		res[spaceView.SpaceID] = SpaceRoleGuest
+	}
+	return res, nil
 }
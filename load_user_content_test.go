@@ -0,0 +1,249 @@
+package notionapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const loadUserContentJSON1 = `{
+	"recordMap": {
+		"notion_user": {
+			"bb760e2d-d679-4b64-b2a9-03005b21870a": {
+				"role": "editor",
+				"value": {
+					"id": "bb760e2d-d679-4b64-b2a9-03005b21870a",
+					"given_name": "Krzysztof"
+				}
+			}
+		},
+		"space": {
+			"cf9fa7dd-b245-42a0-b929-d5a276b3afe0": {
+				"role": "editor",
+				"value": {
+					"id": "cf9fa7dd-b245-42a0-b929-d5a276b3afe0",
+					"name": "Personal",
+					"permissions": [
+						{
+							"role": "editor",
+							"type": "user_permission",
+							"user_id": "bb760e2d-d679-4b64-b2a9-03005b21870a"
+						}
+					]
+				}
+			},
+			"4e548900-40d1-4140-a0a8-165f0c373f6d": {
+				"role": "read_and_write",
+				"value": {
+					"id": "4e548900-40d1-4140-a0a8-165f0c373f6d",
+					"name": "Team",
+					"permissions": [
+						{
+							"role": "editor",
+							"type": "user_permission",
+							"user_id": "2131b10c-ebf6-4938-a127-7089ff02dbe4"
+						},
+						{
+							"role": "read_and_write",
+							"type": "user_permission",
+							"user_id": "bb760e2d-d679-4b64-b2a9-03005b21870a"
+						}
+					]
+				}
+			}
+		},
+		"space_view": {
+			"7e825831-be07-487e-87e7-56e52914233b": {
+				"role": "editor",
+				"value": {
+					"id": "7e825831-be07-487e-87e7-56e52914233b",
+					"alive": true,
+					"space_id": "cf9fa7dd-b245-42a0-b929-d5a276b3afe0",
+					"parent_id": "bb760e2d-d679-4b64-b2a9-03005b21870a",
+					"parent_table": "user_root"
+				}
+			},
+			"c969c945-5d7c-4dd7-9c7f-860f3ace6429": {
+				"role": "editor",
+				"value": {
+					"id": "c969c945-5d7c-4dd7-9c7f-860f3ace6429",
+					"alive": true,
+					"space_id": "300db9dc-27c8-4958-a08b-8d0c37f4cfe5",
+					"parent_id": "bb760e2d-d679-4b64-b2a9-03005b21870a",
+					"parent_table": "user_root"
+				}
+			}
+		}
+	}
+}`
+
+func TestSpaceRolesFromRecordMap(t *testing.T) {
+	var rsp struct {
+		RecordMap userContentRecordMap `json:"recordMap"`
+	}
+	err := json.Unmarshal([]byte(loadUserContentJSON1), &rsp)
+	assert.NoError(t, err)
+	roles, err := spaceRolesFromRecordMap(rsp.RecordMap)
+	assert.NoError(t, err)
+	exp := map[string]string{
+		"cf9fa7dd-b245-42a0-b929-d5a276b3afe0": SpaceRoleOwner,
+		"4e548900-40d1-4140-a0a8-165f0c373f6d": SpaceRoleMember,
+		"300db9dc-27c8-4958-a08b-8d0c37f4cfe5": SpaceRoleGuest,
+	}
+	assert.Equal(t, exp, roles)
+}
+
+func TestDecodeRecordMap(t *testing.T) {
+	got := map[string][]string{}
+	err := DecodeRecordMap(strings.NewReader(loadUserContentJSON1), func(table string, id string, raw json.RawMessage) {
+		got[table] = append(got[table], id)
+		var rec struct {
+			Value struct {
+				ID string `json:"id"`
+			} `json:"value"`
+		}
+		assert.NoError(t, json.Unmarshal(raw, &rec))
+		assert.Equal(t, id, rec.Value.ID)
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"bb760e2d-d679-4b64-b2a9-03005b21870a"}, got[TableUser])
+	assert.Len(t, got[TableSpace], 2)
+	assert.Len(t, got[TableSpaceView], 2)
+
+	// other keys are skipped and null recordMap is fine
+	s := `{"cursor":{"stack":[[1,2]]},"recordMap":null,"other":"x"}`
+	err = DecodeRecordMap(strings.NewReader(s), func(string, string, json.RawMessage) {
+		t.Fatal("unexpected record")
+	})
+	assert.NoError(t, err)
+
+	err = DecodeRecordMap(strings.NewReader(`{"recordMap":[]}`), func(string, string, json.RawMessage) {})
+	assert.Error(t, err)
+}
+
+// genLargeRecordMapJSON generates a response with n blocks
+func genLargeRecordMapJSON(n int) []byte {
+	blocks := map[string]interface{}{}
+	for i := 0; i < n; i++ {
+		id := fmt.Sprintf("00000000-0000-0000-0000-%012d", i)
+		blocks[id] = map[string]interface{}{
+			"role": "editor",
+			"value": map[string]interface{}{
+				"id":         id,
+				"type":       "text",
+				"alive":      true,
+				"properties": map[string]interface{}{"title": [][]string{{strings.Repeat("lorem ipsum ", 20)}}},
+			},
+		}
+	}
+	rsp := map[string]interface{}{
+		"recordMap": map[string]interface{}{"block": blocks},
+	}
+	d, _ := json.Marshal(rsp)
+	return d
+}
+
+func BenchmarkRecordMapUnmarshal(b *testing.B) {
+	d := genLargeRecordMapJSON(10000)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		var rsp struct {
+			RecordMap userContentRecordMap `json:"recordMap"`
+		}
+		if err := json.Unmarshal(d, &rsp); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkRecordMapDecodeStreaming(b *testing.B) {
+	d := genLargeRecordMapJSON(10000)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		n := 0
+		err := DecodeRecordMap(bytes.NewReader(d), func(string, string, json.RawMessage) {
+			n++
+		})
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestUserContentFromRecordMap(t *testing.T) {
+	s := `{
+	"recordMap": {
+		"notion_user": {
+			"bb760e2d-d679-4b64-b2a9-03005b21870a": {"role": "editor", "value": {"id": "bb760e2d-d679-4b64-b2a9-03005b21870a", "given_name": "Krzysztof"}}
+		},
+		"block": {
+			"00000000-0000-0000-0000-000000000003": {"role": "editor", "value": {"id": "00000000-0000-0000-0000-000000000003", "type": "page", "alive": true, "properties": {"title": [["Third"]]}}},
+			"00000000-0000-0000-0000-000000000001": {"role": "editor", "value": {"id": "00000000-0000-0000-0000-000000000001", "type": "page", "alive": true, "properties": {"title": [["First"]]}}},
+			"00000000-0000-0000-0000-000000000002": {"role": "editor", "value": {"id": "00000000-0000-0000-0000-000000000002", "type": "page", "alive": true, "properties": {"title": [["Second"]]}}}
+		}
+	}
+}`
+	var rsp struct {
+		RecordMap userContentRecordMap `json:"recordMap"`
+	}
+	assert.NoError(t, json.Unmarshal([]byte(s), &rsp))
+	res, err := userContentFromRecordMap(rsp.RecordMap)
+	assert.NoError(t, err)
+	var titles []string
+	for _, b := range res.Blocks {
+		titles = append(titles, b.Title)
+	}
+	assert.Equal(t, []string{"First", "Second", "Third"}, titles)
+	if assert.Len(t, res.Users, 1) {
+		assert.Equal(t, "Krzysztof", res.Users[0].GivenName)
+		assert.Equal(t, "Krzysztof", res.Users[0].RawJSON["given_name"])
+	}
+	assert.Empty(t, res.Spaces)
+
+	var rsp2 struct {
+		RecordMap userContentRecordMap `json:"recordMap"`
+	}
+	err = json.Unmarshal([]byte(loadUserContentJSON1), &rsp2)
+	assert.NoError(t, err)
+	res, err = userContentFromRecordMap(rsp2.RecordMap)
+	assert.NoError(t, err)
+	if assert.Len(t, res.Spaces, 2) {
+		assert.Equal(t, "Team", res.Spaces[0].Name)
+		assert.Equal(t, "Personal", res.Spaces[1].Name)
+		assert.Equal(t, "Team", res.Spaces[0].RawJSON["name"])
+	}
+
+	// malformed title is an error
+	badTitle := `{"id": "00000000-0000-0000-0000-000000000001", "type": "page", "properties": {"title": "not spans"}}`
+	recordMap := userContentRecordMap{
+		TableBlock: {"00000000-0000-0000-0000-000000000001": ValueResponse{Value: json.RawMessage(badTitle)}},
+	}
+	_, err = userContentFromRecordMap(recordMap)
+	assert.Error(t, err)
+}
+
+func TestSpaceRolesFromRecordMapMultipleUsers(t *testing.T) {
+	var rsp struct {
+		RecordMap userContentRecordMap `json:"recordMap"`
+	}
+	err := json.Unmarshal([]byte(loadUserContentJSON1), &rsp)
+	assert.NoError(t, err)
+	// the owner of Team space sorts before the authenticated user
+	ownerID := "2131b10c-ebf6-4938-a127-7089ff02dbe4"
+	rsp.RecordMap[TableUser][ownerID] = ValueResponse{
+		Role:  "reader",
+		Value: json.RawMessage(`{"id":"` + ownerID + `"}`),
+	}
+	for i := 0; i < 20; i++ {
+		roles, err := spaceRolesFromRecordMap(rsp.RecordMap)
+		assert.NoError(t, err)
+		assert.Equal(t, SpaceRoleOwner, roles["cf9fa7dd-b245-42a0-b929-d5a276b3afe0"])
+		assert.Equal(t, SpaceRoleMember, roles["4e548900-40d1-4140-a0a8-165f0c373f6d"])
+	}
+}
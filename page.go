@@ -1,9 +1,13 @@
 package notionapi
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
 )
 
 var (
@@ -26,6 +30,8 @@ type Page struct {
 	idToCollection     map[string]*Collection
 	idToCollectionView map[string]*CollectionView
 	blocksToSkip       map[string]struct{} // not alive or when server doesn't return "value" for this block id
+	// RawRecords of a table, keyed by table name
+	rawRecords map[string]map[string]json.RawMessage
 
 	client *Client
 }
@@ -40,6 +46,16 @@ func (p *Page) UserByID(id string) *User {
 	return p.idToUser[ToDashID(id)]
 }
 
+// ReindexUsers updates the index used by UserByID and ResolveUser.
+// Call it after changing Users
+func (p *Page) ReindexUsers() {
+	idToUser := map[string]*User{}
+	for _, u := range p.Users {
+		idToUser[ToDashID(u.ID)] = u
+	}
+	p.idToUser = idToUser
+}
+
 // CollectionByID returns a collection by its id
 func (p *Page) CollectionByID(id string) *Collection {
 	return p.idToCollection[ToDashID(id)]
@@ -55,6 +71,26 @@ func (p *Page) Root() *Block {
 	return p.BlockByID(p.ID)
 }
 
+// Title returns title of the page or empty string if we don't
+// have the root block
+func (p *Page) Title() string {
+	root := p.Root()
+	if root == nil {
+		return ""
+	}
+	return root.Title
+}
+
+// TitleSpans returns title of the page with formatting or nil if we
+// don't have the root block
+func (p *Page) TitleSpans() []*TextSpan {
+	root := p.Root()
+	if root == nil {
+		return nil
+	}
+	return root.GetTitle()
+}
+
 // Table represents a table (i.e. CollectionView)
 type Table struct {
 	CollectionView *CollectionView `json:"collection_view"`
@@ -116,6 +152,223 @@ func (p *Page) ForEachBlock(cb func(*Block)) {
 	forEachBlockWithParent(root.Content, nil, cb)
 }
 
+func walkBlock(block *Block, enter func(*Block) bool, leave func(*Block)) {
+	if !enter(block) {
+		return
+	}
+	for _, child := range block.Content {
+		walkBlock(child, enter, leave)
+	}
+	if leave != nil {
+		leave(block)
+	}
+}
+
+// Walk traverses the tree of blocks in depth-first order, starting
+// with the root. enter is called before visiting children of a block and
+// leave after. If enter returns false, children of the block are skipped
+// and leave is not called for it. leave can be nil
+func (p *Page) Walk(enter func(*Block) bool, leave func(*Block)) {
+	walkBlock(p.Root(), enter, leave)
+}
+
+// BlocksOfType returns all blocks in the page (including the root)
+// whose type is one of types, in document order
+func (p *Page) BlocksOfType(types ...string) []*Block {
+	var res []*Block
+	p.ForEachBlock(func(block *Block) {
+		for _, t := range types {
+			if block.Type == t {
+				res = append(res, block)
+				return
+			}
+		}
+	})
+	return res
+}
+
+// blocks whose text is used in Page.Excerpt
+var excerptBlockTypes = map[string]bool{
+	BlockText:         true,
+	BlockBulletedList: true,
+	BlockNumberedList: true,
+	BlockTodo:         true,
+	BlockToggle:       true,
+	BlockQuote:        true,
+	BlockCallout:      true,
+}
+
+// Excerpt returns plain text of the first text blocks of the page, up to
+// maxChars characters. Longer text is cut on a word boundary and "…"
+// is appended. Headers, code etc. are skipped
+func (p *Page) Excerpt(maxChars int) string {
+	var parts []string
+	n := 0
+	p.Walk(func(block *Block) bool {
+		if n > maxChars {
+			return false
+		}
+		if p.IsRoot(block) {
+			return true
+		}
+		if !excerptBlockTypes[block.Type] {
+			// e.g. sub-pages or columns
+			return block.Type == BlockColumnList || block.Type == BlockColumn
+		}
+		s := strings.Join(strings.Fields(block.PlainText()), " ")
+		if s != "" {
+			parts = append(parts, s)
+			n += utf8.RuneCountInString(s) + 1
+		}
+		return true
+	}, nil)
+	return truncateOnWord(strings.Join(parts, " "), maxChars)
+}
+
+// truncateOnWord shortens s to at most maxChars characters (including
+// the "…" that is appended if s was shortened), cutting between words
+// when possible
+func truncateOnWord(s string, maxChars int) string {
+	runes := []rune(s)
+	if len(runes) <= maxChars {
+		return s
+	}
+	if maxChars < 1 {
+		return ""
+	}
+	cut := runes[:maxChars-1]
+	// cut on a word boundary unless it's the first word
+	if runes[maxChars-1] != ' ' {
+		if idx := strings.LastIndex(string(cut), " "); idx > 0 {
+			cut = []rune(string(cut)[:idx])
+		}
+	}
+	return strings.TrimRight(string(cut), " ,.;:") + "…"
+}
+
+// RawRecords returns raw JSON of records of a given table (TableBlock,
+// TableCollection, TableCollectionView or TableUser) in the page, keyed
+// by id. It's meant for getting data not (yet) exposed by the library.
+// The JSON is Notion's internal format so it's not stable and can change
+// without notice.
+// The result is computed once per table and shared between calls so it
+// must not be modified
+func (p *Page) RawRecords(table string) map[string]json.RawMessage {
+	if res, ok := p.rawRecords[table]; ok {
+		return res
+	}
+	res := map[string]json.RawMessage{}
+	for id, rawJSON := range p.rawJSONOfTable(table) {
+		if d := marshalRawJSON(rawJSON); d != nil {
+			res[id] = d
+		}
+	}
+	if p.rawRecords == nil {
+		p.rawRecords = map[string]map[string]json.RawMessage{}
+	}
+	p.rawRecords[table] = res
+	return res
+}
+
+// RawRecord returns raw JSON of a record with a given id or nil if the
+// page doesn't have it. See RawRecords
+func (p *Page) RawRecord(table string, id string) json.RawMessage {
+	id = ToDashID(id)
+	if res, ok := p.rawRecords[table]; ok {
+		return res[id]
+	}
+	var rawJSON map[string]interface{}
+	switch table {
+	case TableBlock:
+		if b := p.idToBlock[id]; b != nil {
+			rawJSON = b.RawJSON
+		}
+	case TableCollection:
+		if c := p.idToCollection[id]; c != nil {
+			rawJSON = c.RawJSON
+		}
+	case TableCollectionView:
+		if v := p.idToCollectionView[id]; v != nil {
+			rawJSON = v.RawJSON
+		}
+	case TableUser:
+		if u := p.idToUser[id]; u != nil {
+			rawJSON = u.RawJSON
+		}
+	}
+	return marshalRawJSON(rawJSON)
+}
+
+// rawJSONOfTable returns RawJSON of all records of a table, keyed by id
+func (p *Page) rawJSONOfTable(table string) map[string]map[string]interface{} {
+	res := map[string]map[string]interface{}{}
+	switch table {
+	case TableBlock:
+		for id, b := range p.idToBlock {
+			res[id] = b.RawJSON
+		}
+	case TableCollection:
+		for id, c := range p.idToCollection {
+			res[id] = c.RawJSON
+		}
+	case TableCollectionView:
+		for id, v := range p.idToCollectionView {
+			res[id] = v.RawJSON
+		}
+	case TableUser:
+		for id, u := range p.idToUser {
+			res[id] = u.RawJSON
+		}
+	}
+	return res
+}
+
+func marshalRawJSON(rawJSON map[string]interface{}) json.RawMessage {
+	if rawJSON == nil {
+		return nil
+	}
+	d, err := json.Marshal(rawJSON)
+	if err != nil {
+		return nil
+	}
+	return d
+}
+
+// PageImage is an image shown in a page
+type PageImage struct {
+	// BlockImage or, for page cover, the root page block
+	Block *Block
+	// url of the image, proxied through notion.so if hosted by Notion
+	URL string
+}
+
+// GetImages returns images in the page in document order. If includeCover
+// is true and the page has a cover image, it's the first image
+func (p *Page) GetImages(includeCover bool) []*PageImage {
+	var res []*PageImage
+	root := p.Root()
+	if includeCover && root != nil {
+		if cover, _ := root.PropAsString("format.page_cover"); cover != "" {
+			// built-in covers are relative to notion.so
+			if strings.HasPrefix(cover, "/") {
+				cover = "https://www.notion.so" + cover
+			}
+			res = append(res, &PageImage{Block: root, URL: maybeProxyImageURL(cover)})
+		}
+	}
+	for _, block := range p.BlocksOfType(BlockImage) {
+		uri := block.ImageURL
+		if uri == "" && block.Source != "" {
+			uri = maybeProxyImageURL(block.Source)
+		}
+		if uri == "" {
+			continue
+		}
+		res = append(res, &PageImage{Block: block, URL: uri})
+	}
+	return res
+}
+
 func panicIf(cond bool, args ...interface{}) {
 	if !cond {
 		return
@@ -192,6 +445,72 @@ func (p *Page) GetSubPages() []string {
 	return res
 }
 
+// ChildPage is a page that is a direct child of a page
+type ChildPage struct {
+	// BlockPage or BlockCollectionViewPage
+	Block *Block
+	// for BlockCollectionViewPage it's the name of the collection
+	Title string
+}
+
+// GetChildPages returns BlockPage and BlockCollectionViewPage blocks that
+// are direct children of the root block, in the order they appear on the
+// page. Unlike GetSubPages it doesn't look inside other blocks.
+// Links to pages that are not children of the page are skipped
+func (p *Page) GetChildPages() []*ChildPage {
+	var res []*ChildPage
+	root := p.Root()
+	for _, block := range root.Content {
+		if block.Type != BlockPage && block.Type != BlockCollectionViewPage {
+			continue
+		}
+		if block.ParentID != root.ID {
+			continue
+		}
+		title := block.Title
+		if title == "" && block.Type == BlockCollectionViewPage {
+			if col := p.CollectionByID(block.CollectionID); col != nil {
+				title = col.Name()
+			}
+		}
+		res = append(res, &ChildPage{Block: block, Title: title})
+	}
+	return res
+}
+
+// WordCount returns number of words in the content of the page,
+// not counting code blocks
+func (p *Page) WordCount() int {
+	return p.CountWords(false)
+}
+
+// CountWords returns number of words in the content of the page.
+// If includeCode is true, words in code blocks are also counted
+func (p *Page) CountWords(includeCode bool) int {
+	n := 0
+	p.ForEachBlock(func(block *Block) {
+		if p.IsRoot(block) {
+			return
+		}
+		if block.Type == BlockCode && !includeCode {
+			return
+		}
+		s := block.PlainText()
+		n += len(strings.Fields(s))
+	})
+	return n
+}
+
+// ReadingTime returns an estimated time to read the page given
+// reading speed in words per minute
+func (p *Page) ReadingTime(wordsPerMinute int) time.Duration {
+	if wordsPerMinute <= 0 {
+		return 0
+	}
+	n := p.WordCount()
+	return time.Duration(n) * time.Minute / time.Duration(wordsPerMinute)
+}
+
 func makeUserName(user *User) string {
 	s := user.GivenName
 	if len(s) > 0 {
@@ -204,13 +523,12 @@ func makeUserName(user *User) string {
 	return user.ID
 }
 
+// ResolveUser returns a name of a user with a given id or userID
+// if the page doesn't know about this user
 func ResolveUser(page *Page, userID string) string {
-	// TODO: either scan for user ids when initially downloading a page
-	// or do a query if not found
-	for _, u := range page.Users {
-		if u.ID == userID {
-			return makeUserName(u)
-		}
+	// TODO: do a query if not found
+	if u := page.UserByID(userID); u != nil {
+		return makeUserName(u)
 	}
 	return userID
 }
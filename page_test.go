@@ -0,0 +1,301 @@
+package notionapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestBlock(id string, blockType string, title string, children ...*Block) *Block {
+	b := &Block{
+		ID:         ToDashID(id),
+		Type:       blockType,
+		Alive:      true,
+		Properties: map[string]interface{}{},
+	}
+	if title != "" {
+		b.Properties["title"] = []interface{}{[]interface{}{title}}
+	}
+	for _, child := range children {
+		child.ParentID = b.ID
+		child.ParentTable = TableBlock
+		b.ContentIDs = append(b.ContentIDs, child.ID)
+	}
+	return b
+}
+
+// newTestPage creates a page from blocks. First block is the root
+func newTestPage(blocks ...*Block) *Page {
+	p := &Page{
+		ID:                 blocks[0].ID,
+		idToBlock:          map[string]*Block{},
+		idToCollection:     map[string]*Collection{},
+		idToCollectionView: map[string]*CollectionView{},
+		idToUser:           map[string]*User{},
+		blocksToSkip:       map[string]struct{}{},
+	}
+	for _, b := range blocks {
+		p.idToBlock[b.ID] = b
+		b.Page = p
+	}
+	p.resolveBlocks()
+	return p
+}
+
+func TestWordCount(t *testing.T) {
+	code := newTestBlock("c0de0000000000000000000000000005", BlockCode, "fmt.Println(a, b)")
+	text1 := newTestBlock("00000000000000000000000000000002", BlockText, "one two three")
+	text2 := newTestBlock("00000000000000000000000000000004", BlockText, "six  seven")
+	list := newTestBlock("00000000000000000000000000000003", BlockBulletedList, "four five", text2)
+	root := newTestBlock("00000000000000000000000000000001", BlockPage, "Page title", text1, list, code)
+	page := newTestPage(root, text1, list, text2, code)
+
+	assert.Equal(t, 7, page.WordCount())
+	assert.Equal(t, 9, page.CountWords(true))
+	assert.Equal(t, 7*time.Minute/2, page.ReadingTime(2))
+	assert.Equal(t, time.Duration(0), page.ReadingTime(0))
+}
+
+func TestBlocksOfType(t *testing.T) {
+	h1 := newTestBlock("00000000000000000000000000000002", BlockHeader, "h1")
+	h2 := newTestBlock("00000000000000000000000000000004", BlockSubHeader, "h2")
+	h3 := newTestBlock("00000000000000000000000000000005", BlockSubSubHeader, "h3")
+	toggle := newTestBlock("00000000000000000000000000000003", BlockToggle, "toggle", h2, h3)
+	text := newTestBlock("00000000000000000000000000000006", BlockText, "text")
+	h4 := newTestBlock("00000000000000000000000000000007", BlockHeader, "h4")
+	root := newTestBlock("00000000000000000000000000000001", BlockPage, "Page title", h1, toggle, text, h4)
+	page := newTestPage(root, h1, toggle, h2, h3, text, h4)
+
+	headers := page.BlocksOfType(BlockHeader, BlockSubHeader, BlockSubSubHeader)
+	var titles []string
+	for _, b := range headers {
+		titles = append(titles, TextSpansToString(b.InlineContent))
+	}
+	assert.Equal(t, []string{"h1", "h2", "h3", "h4"}, titles)
+	assert.Empty(t, page.BlocksOfType(BlockImage))
+}
+
+func TestWalk(t *testing.T) {
+	text1 := newTestBlock("00000000000000000000000000000003", BlockText, "text1")
+	toggle := newTestBlock("00000000000000000000000000000002", BlockToggle, "toggle", text1)
+	text2 := newTestBlock("00000000000000000000000000000005", BlockText, "text2")
+	list := newTestBlock("00000000000000000000000000000004", BlockBulletedList, "list", text2)
+	root := newTestBlock("00000000000000000000000000000001", BlockPage, "root", toggle, list)
+	page := newTestPage(root, toggle, text1, list, text2)
+
+	name := func(b *Block) string {
+		return TextSpansToString(b.InlineContent)
+	}
+	var events []string
+	enter := func(b *Block) bool {
+		events = append(events, "enter "+name(b))
+		return true
+	}
+	leave := func(b *Block) {
+		events = append(events, "leave "+name(b))
+	}
+	page.Walk(enter, leave)
+	exp := []string{
+		"enter root",
+		"enter toggle", "enter text1", "leave text1", "leave toggle",
+		"enter list", "enter text2", "leave text2", "leave list",
+		"leave root",
+	}
+	assert.Equal(t, exp, events)
+
+	// returning false from enter skips children
+	events = nil
+	page.Walk(func(b *Block) bool {
+		events = append(events, "enter "+name(b))
+		return b.Type != BlockToggle
+	}, leave)
+	exp = []string{
+		"enter root",
+		"enter toggle",
+		"enter list", "enter text2", "leave text2", "leave list",
+		"leave root",
+	}
+	assert.Equal(t, exp, events)
+}
+
+func TestPageTitle(t *testing.T) {
+	root := newTestBlock("00000000000000000000000000000001", BlockPage, "Page title")
+	page := newTestPage(root)
+	assert.Equal(t, "Page title", page.Title())
+	assert.Equal(t, "Page title", TextSpansToString(page.TitleSpans()))
+
+	// page without the root block
+	page = newTestPage(root)
+	page.ID = "00000000-0000-0000-0000-000000000002"
+	assert.Equal(t, "", page.Title())
+	assert.Nil(t, page.TitleSpans())
+}
+
+func TestGetImages(t *testing.T) {
+	img1 := newTestBlock("00000000000000000000000000000002", BlockImage, "")
+	img1.Properties["source"] = []interface{}{[]interface{}{"https://example.com/a.png"}}
+	img2 := newTestBlock("00000000000000000000000000000004", BlockImage, "")
+	img2.Properties["source"] = []interface{}{[]interface{}{"https://s3-us-west-2.amazonaws.com/secure.notion-static.com/b.png"}}
+	toggle := newTestBlock("00000000000000000000000000000003", BlockToggle, "more", img2)
+	root := newTestBlock("00000000000000000000000000000001", BlockPage, "Page", img1, toggle)
+	root.RawJSON = map[string]interface{}{
+		"format": map[string]interface{}{"page_cover": "/images/page-cover/woodcuts_1.jpg"},
+	}
+	page := newTestPage(root, img1, toggle, img2)
+
+	images := page.GetImages(true)
+	assert.Len(t, images, 3)
+	assert.Equal(t, root, images[0].Block)
+	assert.Equal(t, "https://www.notion.so/image/https:%2F%2Fwww.notion.so%2Fimages%2Fpage-cover%2Fwoodcuts_1.jpg", images[0].URL)
+	assert.Equal(t, img1, images[1].Block)
+	assert.Equal(t, "https://example.com/a.png", images[1].URL)
+	assert.Equal(t, img2, images[2].Block)
+	assert.Equal(t, "https://www.notion.so/signed/https:%2F%2Fs3-us-west-2.amazonaws.com%2Fsecure.notion-static.com%2Fb.png", images[2].URL)
+
+	images = page.GetImages(false)
+	assert.Len(t, images, 2)
+	assert.Equal(t, img1, images[0].Block)
+}
+
+func TestExcerpt(t *testing.T) {
+	h := newTestBlock("00000000000000000000000000000002", BlockHeader, "Introduction")
+	t1 := newTestBlock("00000000000000000000000000000003", BlockText, "Notion is a note taking app.")
+	code := newTestBlock("00000000000000000000000000000004", BlockCode, "x := 1")
+	t2 := newTestBlock("00000000000000000000000000000005", BlockBulletedList, "It has   blocks")
+	root := newTestBlock("00000000000000000000000000000001", BlockPage, "Page", h, t1, code, t2)
+	page := newTestPage(root, h, t1, code, t2)
+
+	// shorter than the limit
+	assert.Equal(t, "Notion is a note taking app. It has blocks", page.Excerpt(100))
+	assert.Equal(t, "Notion is a note taking app. It has blocks", page.Excerpt(42))
+
+	// longer than the limit, cut on a word
+	assert.Equal(t, "Notion is a note…", page.Excerpt(20))
+	assert.Equal(t, "Notion is a note taking app…", page.Excerpt(30))
+	assert.Equal(t, "Notio…", page.Excerpt(6))
+	assert.Equal(t, "", page.Excerpt(0))
+}
+
+func TestRawRecords(t *testing.T) {
+	child := newTestBlock("00000000000000000000000000000002", BlockText, "text")
+	child.RawJSON = map[string]interface{}{
+		"id":              child.ID,
+		"type":            "text",
+		"unsupported_key": "value",
+	}
+	root := newTestBlock("00000000000000000000000000000001", BlockPage, "Page", child)
+	page := newTestPage(root, child)
+
+	raw := page.RawRecord(TableBlock, "00000000000000000000000000000002")
+	require.NotNil(t, raw)
+	var v struct {
+		Unsupported string `json:"unsupported_key"`
+	}
+	require.NoError(t, json.Unmarshal(raw, &v))
+	assert.Equal(t, "value", v.Unsupported)
+
+	// root doesn't have raw JSON
+	assert.Len(t, page.RawRecords(TableBlock), 1)
+	assert.Nil(t, page.RawRecord(TableBlock, root.ID))
+	assert.Len(t, page.RawRecords(TableCollection), 0)
+
+	// RawRecords is computed once per table
+	records := page.RawRecords(TableBlock)
+	records["marker"] = nil
+	assert.Contains(t, page.RawRecords(TableBlock), "marker")
+	assert.Equal(t, raw, page.RawRecord(TableBlock, child.ID))
+}
+
+func TestGetChildPages(t *testing.T) {
+	grandChild := newTestBlock("00000000000000000000000000000004", BlockPage, "grandchild")
+	sub1 := newTestBlock("00000000000000000000000000000002", BlockPage, "sub1", grandChild)
+	text := newTestBlock("00000000000000000000000000000003", BlockText, "text")
+	sub2 := newTestBlock("00000000000000000000000000000005", BlockCollectionViewPage, "")
+	sub2.CollectionID = "00000000-0000-0000-0000-000000000010"
+	link := newTestBlock("00000000000000000000000000000006", BlockPage, "elsewhere")
+	root := newTestBlock("00000000000000000000000000000001", BlockPage, "root", sub1, text, sub2, link)
+	// a link to a page that has a different parent
+	link.ParentID = "00000000-0000-0000-0000-000000000099"
+	page := newTestPage(root, sub1, grandChild, text, sub2, link)
+	page.idToCollection[sub2.CollectionID] = &Collection{
+		ID:      sub2.CollectionID,
+		RawJSON: map[string]interface{}{"name": []interface{}{[]interface{}{"Database"}}},
+	}
+
+	pages := page.GetChildPages()
+	if assert.Len(t, pages, 2) {
+		assert.Equal(t, sub1, pages[0].Block)
+		assert.Equal(t, "sub1", pages[0].Title)
+		assert.Equal(t, sub2, pages[1].Block)
+		assert.Equal(t, "Database", pages[1].Title)
+	}
+	// the block is not modified
+	assert.Equal(t, "", sub2.Title)
+}
+
+func TestResolveUser(t *testing.T) {
+	page := newTestPage(newTestBlock("00000000000000000000000000000001", BlockPage, "root"))
+	page.Users = []*User{
+		{ID: "00000000-0000-0000-0000-0000000000a1", GivenName: "Ada", FamilyName: "Lovelace"},
+	}
+	page.ReindexUsers()
+	assert.Equal(t, "Ada Lovelace", ResolveUser(page, "00000000-0000-0000-0000-0000000000a1"))
+	assert.Equal(t, "Ada", page.UserByID("000000000000000000000000000000a1").GivenName)
+	assert.Equal(t, "unknown", ResolveUser(page, "unknown"))
+
+	page.Users = []*User{
+		{ID: "00000000-0000-0000-0000-0000000000a2", GivenName: "Alan", FamilyName: "Turing"},
+	}
+	page.ReindexUsers()
+	assert.Equal(t, "Alan Turing", ResolveUser(page, "00000000-0000-0000-0000-0000000000a2"))
+	assert.Equal(t, "00000000-0000-0000-0000-0000000000a1", ResolveUser(page, "00000000-0000-0000-0000-0000000000a1"))
+}
+
+// resolveUserLinear is how ResolveUser used to find users
+func resolveUserLinear(page *Page, userID string) string {
+	for _, u := range page.Users {
+		if u.ID == userID {
+			return makeUserName(u)
+		}
+	}
+	return userID
+}
+
+func BenchmarkResolveUser(b *testing.B) {
+	page := newTestPage(newTestBlock("00000000000000000000000000000001", BlockPage, "root"))
+	var mentions, unresolved []string
+	for i := 0; i < 1000; i++ {
+		id := fmt.Sprintf("00000000-0000-0000-0000-%012d", i)
+		page.Users = append(page.Users, &User{ID: id, GivenName: fmt.Sprintf("user%d", i)})
+		mentions = append(mentions, id)
+		unresolved = append(unresolved, fmt.Sprintf("00000000-0000-0000-0001-%012d", i))
+	}
+	page.ReindexUsers()
+	for _, ids := range []struct {
+		name string
+		ids  []string
+	}{
+		{"resolved", mentions},
+		{"unresolved", unresolved},
+	} {
+		ids := ids
+		b.Run("linear-"+ids.name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				for _, id := range ids.ids {
+					resolveUserLinear(page, id)
+				}
+			}
+		})
+		b.Run("cached-"+ids.name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				for _, id := range ids.ids {
+					ResolveUser(page, id)
+				}
+			}
+		})
+	}
+}
@@ -1,5 +1,7 @@
 package notionapi
 
+import "context"
+
 // /api/v3/queryCollection request
 type queryCollectionRequest struct {
 	CollectionID     string           `json:"collectionId"`
@@ -61,6 +63,10 @@ type AggregationResult struct {
 
 // QueryCollection executes a raw API call /api/v3/queryCollection
 func (c *Client) QueryCollection(collectionID, collectionViewID string, aggregateQuery []*AggregateQuery, user *User) (*QueryCollectionResponse, error) {
+	return c.queryCollectionCtx(context.Background(), collectionID, collectionViewID, aggregateQuery, user)
+}
+
+func (c *Client) queryCollectionCtx(ctx context.Context, collectionID, collectionViewID string, aggregateQuery []*AggregateQuery, user *User) (*QueryCollectionResponse, error) {
 	req := &queryCollectionRequest{
 		CollectionID:     collectionID,
 		CollectionViewID: collectionViewID,
@@ -81,7 +87,7 @@ func (c *Client) QueryCollection(collectionID, collectionViewID string, aggregat
 	apiURL := "/api/v3/queryCollection"
 	var rsp QueryCollectionResponse
 	var err error
-	rsp.RawJSON, err = doNotionAPI(c, apiURL, req, &rsp)
+	rsp.RawJSON, err = doNotionAPICtx(ctx, c, apiURL, req, &rsp)
 	if err != nil {
 		return nil, err
 	}
@@ -1,9 +1,30 @@
 package notionapi
 
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"time"
+)
+
 type submitTransactionRequest struct {
+	// generated by us. The same for all retries of the same
+	// transaction so that Notion can detect duplicates
+	RequestID  string       `json:"requestId"`
 	Operations []*Operation `json:"operations"`
 }
 
+var (
+	// how many times we retry submitTransaction after a network error
+	// or a server error
+	submitTransactionMaxRetries = 3
+	// a variable so that tests don't have to wait
+	submitTransactionRetryDelay = time.Second
+)
+
 // Operation describes a single operation sent
 type Operation struct {
 	ID      string      `json:"id"`      // id of the block being modified
@@ -13,14 +34,50 @@ type Operation struct {
 	Args    interface{} `json:"args"`
 }
 
+// isRetryableError returns true for network errors and server errors
+// i.e. errors where the request might succeed if repeated
+func isRetryableError(err error) bool {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode >= 500 || apiErr.StatusCode == http.StatusTooManyRequests
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	if errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, io.EOF) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+// SubmitTransaction executes operations as a single transaction.
+// Requests that fail with a network or server error are retried
+// with the same request id. A failed request might have been applied
+// so transactions are executed at least once and Notion uses the
+// request id to avoid applying them twice
 func (c *Client) SubmitTransaction(ops []*Operation) error {
+	requestID, err := newBlockID()
+	if err != nil {
+		return err
+	}
 	req := &submitTransactionRequest{
+		RequestID:  requestID,
 		Operations: ops,
 	}
-	// response is empty, as far as I can tell
-	var rsp map[string]interface{}
 	apiURL := "/api/v3/submitTransaction"
-	_, err := doNotionAPI(c, apiURL, req, &rsp)
+	for i := 0; i <= submitTransactionMaxRetries; i++ {
+		if i > 0 {
+			dbg(c, "retrying submitTransaction %s after error %s\n", req.RequestID, err)
+			time.Sleep(submitTransactionRetryDelay)
+		}
+		// response is empty, as far as I can tell
+		var rsp map[string]interface{}
+		_, err = doNotionAPI(c, apiURL, req, &rsp)
+		if err == nil || !isRetryableError(err) {
+			return err
+		}
+	}
 	return err
 }
 
@@ -58,6 +115,140 @@ func buildLastEditedTimeOp(id string) *Operation {
 }
 */
 
+func buildListRemoveOp(parentID string, blockID string) *Operation {
+	return &Operation{
+		ID:      parentID,
+		Table:   "block",
+		Path:    []string{"content"},
+		Command: "listRemove",
+		Args: map[string]interface{}{
+			"id": blockID,
+		},
+	}
+}
+
+// if afterID is empty, block is inserted as the first child
+func buildListInsertOp(parentID string, blockID string, afterID string) *Operation {
+	args := map[string]interface{}{
+		"id": blockID,
+	}
+	cmd := "listBefore"
+	if afterID != "" {
+		cmd = "listAfter"
+		args["after"] = afterID
+	}
+	return &Operation{
+		ID:      parentID,
+		Table:   "block",
+		Path:    []string{"content"},
+		Command: cmd,
+		Args:    args,
+	}
+}
+
+func buildSetParentOp(blockID string, parentID string) *Operation {
+	return &Operation{
+		ID:      blockID,
+		Table:   "block",
+		Path:    []string{},
+		Command: "update",
+		Args: map[string]interface{}{
+			"parent_id":    parentID,
+			"parent_table": "block",
+			"alive":        true,
+		},
+	}
+}
+
+// canContainBlock returns true if block of type parentType can have
+// a child of type childType
+func canContainBlock(parentType string, childType string) bool {
+	// columns can only be inside column list and column list
+	// can only contain columns
+	if parentType == BlockColumnList || childType == BlockColumn {
+		return parentType == BlockColumnList && childType == BlockColumn
+	}
+	switch parentType {
+	case BlockPage, BlockColumn, BlockToggle, BlockText,
+		BlockBulletedList, BlockNumberedList, BlockTodo,
+		BlockQuote, BlockCallout:
+		return true
+	}
+	return false
+}
+
+// MoveBlock moves block blockID to be a child of newParentID, after
+// block afterID. If afterID is empty, the block becomes the first child
+func (c *Client) MoveBlock(blockID, newParentID string, afterID string) error {
+	blockID = ToDashID(blockID)
+	newParentID = ToDashID(newParentID)
+	if afterID != "" {
+		afterID = ToDashID(afterID)
+	}
+	if blockID == newParentID {
+		return fmt.Errorf("can't move block '%s' into itself", blockID)
+	}
+	rsp, err := c.GetRecordValues([]string{blockID, newParentID})
+	if err != nil {
+		return err
+	}
+	if len(rsp.Results) != 2 {
+		return fmt.Errorf("expected 2 results from getRecordValues, got %d", len(rsp.Results))
+	}
+	block := rsp.Results[0].Value
+	if block == nil {
+		return fmt.Errorf("block '%s' doesn't exist", blockID)
+	}
+	newParent := rsp.Results[1].Value
+	if newParent == nil {
+		return fmt.Errorf("block '%s' doesn't exist", newParentID)
+	}
+	if !canContainBlock(newParent.Type, block.Type) {
+		return fmt.Errorf("block of type '%s' can't contain block of type '%s'", newParent.Type, block.Type)
+	}
+	isDescendant, err := c.isDescendantOf(newParent, blockID)
+	if err != nil {
+		return err
+	}
+	if isDescendant {
+		return fmt.Errorf("can't move block '%s' into its descendant '%s'", blockID, newParentID)
+	}
+
+	var ops []*Operation
+	if block.ParentTable == TableBlock && block.ParentID != "" {
+		ops = append(ops, buildListRemoveOp(block.ParentID, blockID))
+	}
+	ops = append(ops, buildSetParentOp(blockID, newParentID))
+	ops = append(ops, buildListInsertOp(newParentID, blockID, afterID))
+	return c.SubmitTransaction(ops)
+}
+
+// isDescendantOf returns true if block is in a subtree of a block with
+// ancestorID. It walks up parents of the block so a malformed block tree
+// with a cycle is possible and visited blocks are tracked
+func (c *Client) isDescendantOf(block *Block, ancestorID string) (bool, error) {
+	visited := map[string]bool{block.ID: true}
+	for block.ParentTable == TableBlock && block.ParentID != "" {
+		parentID := ToDashID(block.ParentID)
+		if parentID == ancestorID {
+			return true, nil
+		}
+		if visited[parentID] {
+			return false, nil
+		}
+		visited[parentID] = true
+		rsp, err := c.GetRecordValues([]string{parentID})
+		if err != nil {
+			return false, err
+		}
+		if len(rsp.Results) != 1 || rsp.Results[0].Value == nil {
+			return false, nil
+		}
+		block = rsp.Results[0].Value
+	}
+	return false, nil
+}
+
 func buildSetPageFormat(id string, args map[string]interface{}) *Operation {
 	return &Operation{
 		ID:      id,
@@ -68,6 +259,36 @@ func buildSetPageFormat(id string, args map[string]interface{}) *Operation {
 	}
 }
 
+func buildSetFormatValueOp(id string, key string, value string) *Operation {
+	return &Operation{
+		ID:      id,
+		Table:   "block",
+		Path:    []string{"format", key},
+		Command: "set",
+		Args:    value,
+	}
+}
+
+func (c *Client) setPageFormatValue(pageID string, key string, value string) error {
+	id := ToDashID(pageID)
+	if !IsValidDashID(id) {
+		return fmt.Errorf("'%s' is not a valid notion id", pageID)
+	}
+	op := buildSetFormatValueOp(id, key, value)
+	return c.SubmitTransaction([]*Operation{op})
+}
+
+// SetPageIcon sets the icon of a page. icon is an emoji (e.g. "🚀")
+// or url of an image
+func (c *Client) SetPageIcon(pageID string, icon string) error {
+	return c.setPageFormatValue(pageID, "page_icon", icon)
+}
+
+// SetPageCover sets the cover image of a page to an image at coverURL
+func (c *Client) SetPageCover(pageID string, coverURL string) error {
+	return c.setPageFormatValue(pageID, "page_cover", coverURL)
+}
+
 /*
 // TODO: add constants for known languages
 func buildUpdateCodeBlockLang(id string, lang string) *Operation {
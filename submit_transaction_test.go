@@ -0,0 +1,241 @@
+package notionapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func jsonResponse(v interface{}) (*http.Response, error) {
+	d, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return &http.Response{
+		StatusCode: 200,
+		Body:       ioutil.NopCloser(bytes.NewReader(d)),
+		Header:     http.Header{},
+	}, nil
+}
+
+// newBlocksTestClient returns a client that knows about blocks and
+// records operations sent with submitTransaction (if ops is not nil)
+func newBlocksTestClient(blocks map[string]*Block, ops *[]*Operation) *Client {
+	return newTestClient(func(r *http.Request) (*http.Response, error) {
+		d, _ := ioutil.ReadAll(r.Body)
+		switch r.URL.Path {
+		case "/api/v3/getRecordValues":
+			var req getRecordValuesRequest
+			_ = json.Unmarshal(d, &req)
+			var results []interface{}
+			for _, rv := range req.Requests {
+				results = append(results, map[string]interface{}{
+					"role":  "editor",
+					"value": blocks[rv.ID],
+				})
+			}
+			return jsonResponse(map[string]interface{}{"results": results})
+		case "/api/v3/submitTransaction":
+			var req submitTransactionRequest
+			_ = json.Unmarshal(d, &req)
+			if ops != nil {
+				*ops = append(*ops, req.Operations...)
+			}
+		}
+		return jsonResponse(map[string]interface{}{})
+	})
+}
+
+func TestMoveBlock(t *testing.T) {
+	blockID := "00000000-0000-0000-0000-000000000003"
+	oldParentID := "00000000-0000-0000-0000-000000000001"
+	newParentID := "00000000-0000-0000-0000-000000000002"
+	afterID := "00000000-0000-0000-0000-000000000004"
+	blocks := map[string]*Block{
+		blockID:     {ID: blockID, Type: BlockText, ParentID: oldParentID, ParentTable: TableBlock},
+		newParentID: {ID: newParentID, Type: BlockToggle, ParentID: oldParentID, ParentTable: TableBlock},
+		oldParentID: {ID: oldParentID, Type: BlockColumnList},
+	}
+	var ops []*Operation
+	client := newBlocksTestClient(blocks, &ops)
+
+	err := client.MoveBlock(ToNoDashID(blockID), newParentID, afterID)
+	require.NoError(t, err)
+	require.Len(t, ops, 3)
+
+	assert.Equal(t, "listRemove", ops[0].Command)
+	assert.Equal(t, oldParentID, ops[0].ID)
+	assert.Equal(t, []string{"content"}, ops[0].Path)
+	assert.Equal(t, map[string]interface{}{"id": blockID}, ops[0].Args)
+
+	assert.Equal(t, "update", ops[1].Command)
+	assert.Equal(t, blockID, ops[1].ID)
+	args := ops[1].Args.(map[string]interface{})
+	assert.Equal(t, newParentID, args["parent_id"])
+	assert.Equal(t, TableBlock, args["parent_table"])
+
+	assert.Equal(t, "listAfter", ops[2].Command)
+	assert.Equal(t, newParentID, ops[2].ID)
+	assert.Equal(t, map[string]interface{}{"id": blockID, "after": afterID}, ops[2].Args)
+
+	// without afterID it becomes the first child
+	ops = nil
+	err = client.MoveBlock(blockID, newParentID, "")
+	require.NoError(t, err)
+	require.Len(t, ops, 3)
+	assert.Equal(t, "listBefore", ops[2].Command)
+	assert.Equal(t, map[string]interface{}{"id": blockID}, ops[2].Args)
+
+	// column list can only contain columns
+	ops = nil
+	err = client.MoveBlock(blockID, oldParentID, "")
+	assert.Error(t, err)
+	assert.Empty(t, ops)
+}
+
+func TestMoveBlockIntoDescendant(t *testing.T) {
+	rootID := "00000000-0000-0000-0000-000000000001"
+	childID := "00000000-0000-0000-0000-000000000002"
+	grandChildID := "00000000-0000-0000-0000-000000000003"
+	loopID1 := "00000000-0000-0000-0000-000000000004"
+	loopID2 := "00000000-0000-0000-0000-000000000005"
+	blocks := map[string]*Block{
+		rootID:       {ID: rootID, Type: BlockToggle},
+		childID:      {ID: childID, Type: BlockToggle, ParentID: rootID, ParentTable: TableBlock},
+		grandChildID: {ID: grandChildID, Type: BlockToggle, ParentID: childID, ParentTable: TableBlock},
+		// malformed: parents of each other
+		loopID1: {ID: loopID1, Type: BlockToggle, ParentID: loopID2, ParentTable: TableBlock},
+		loopID2: {ID: loopID2, Type: BlockToggle, ParentID: loopID1, ParentTable: TableBlock},
+	}
+	var ops []*Operation
+	client := newBlocksTestClient(blocks, &ops)
+
+	err := client.MoveBlock(rootID, grandChildID, "")
+	assert.Error(t, err)
+	assert.Empty(t, ops)
+
+	// a cycle in parents doesn't hang
+	err = client.MoveBlock(childID, loopID1, "")
+	require.NoError(t, err)
+	assert.Len(t, ops, 3)
+}
+
+func TestSubmitTransactionRetry(t *testing.T) {
+	submitTransactionRetryDelay = time.Millisecond
+	defer func() { submitTransactionRetryDelay = time.Second }()
+
+	var requestIDs []string
+	nFailures := 2
+	status := http.StatusBadGateway
+	client := newTestClient(func(r *http.Request) (*http.Response, error) {
+		d, _ := ioutil.ReadAll(r.Body)
+		var req submitTransactionRequest
+		require.NoError(t, json.Unmarshal(d, &req))
+		requestIDs = append(requestIDs, req.RequestID)
+		if nFailures > 0 {
+			nFailures--
+			return &http.Response{
+				StatusCode: status,
+				Body:       ioutil.NopCloser(strings.NewReader("")),
+				Header:     http.Header{},
+			}, nil
+		}
+		return jsonResponse(map[string]interface{}{})
+	})
+	op := buildSetTitleOp("00000000-0000-0000-0000-000000000001", "title")
+	err := client.SubmitTransaction([]*Operation{op})
+	require.NoError(t, err)
+	require.Len(t, requestIDs, 3)
+	assert.NotEmpty(t, requestIDs[0])
+	assert.Equal(t, requestIDs[0], requestIDs[1])
+	assert.Equal(t, requestIDs[0], requestIDs[2])
+
+	// a new transaction gets a new id
+	err = client.SubmitTransaction([]*Operation{op})
+	require.NoError(t, err)
+	assert.NotEqual(t, requestIDs[0], requestIDs[3])
+
+	// client errors are not retried
+	requestIDs = nil
+	nFailures = 1
+	status = http.StatusBadRequest
+	err = client.SubmitTransaction([]*Operation{op})
+	assert.Error(t, err)
+	assert.Len(t, requestIDs, 1)
+
+	// network errors are retried
+	nCalls := 0
+	client = newTestClient(func(r *http.Request) (*http.Response, error) {
+		nCalls++
+		if nCalls == 1 {
+			return nil, io.ErrUnexpectedEOF
+		}
+		return jsonResponse(map[string]interface{}{})
+	})
+	err = client.SubmitTransaction([]*Operation{op})
+	require.NoError(t, err)
+	assert.Equal(t, 2, nCalls)
+
+	// local errors are not retried
+	nCalls = 0
+	nTokenCalls := 0
+	client.TokenProvider = func() (string, error) {
+		nTokenCalls++
+		return "", errors.New("no token")
+	}
+	err = client.SubmitTransaction([]*Operation{op})
+	assert.Error(t, err)
+	assert.Equal(t, 1, nTokenCalls)
+	assert.Equal(t, 0, nCalls)
+}
+
+func TestIsRetryableError(t *testing.T) {
+	assert.True(t, isRetryableError(&APIError{StatusCode: http.StatusServiceUnavailable}))
+	assert.True(t, isRetryableError(&APIError{StatusCode: http.StatusTooManyRequests}))
+	assert.False(t, isRetryableError(&APIError{StatusCode: http.StatusNotFound}))
+	assert.True(t, isRetryableError(&net.OpError{Op: "dial", Err: errors.New("connection refused")}))
+	assert.True(t, isRetryableError(io.ErrUnexpectedEOF))
+	assert.False(t, isRetryableError(context.Canceled))
+	assert.False(t, isRetryableError(errors.New("json: unsupported value")))
+}
+
+func TestSetPageIconAndCover(t *testing.T) {
+	pageID := "00000000-0000-0000-0000-000000000001"
+	var ops []*Operation
+	client := newBlocksTestClient(nil, &ops)
+
+	err := client.SetPageIcon(ToNoDashID(pageID), "🚀")
+	require.NoError(t, err)
+	err = client.SetPageIcon(pageID, "https://example.com/icon.png")
+	require.NoError(t, err)
+	err = client.SetPageCover(pageID, "https://example.com/cover.jpg")
+	require.NoError(t, err)
+	require.Len(t, ops, 3)
+
+	for _, op := range ops {
+		assert.Equal(t, pageID, op.ID)
+		assert.Equal(t, TableBlock, op.Table)
+		assert.Equal(t, "set", op.Command)
+	}
+	assert.Equal(t, []string{"format", "page_icon"}, ops[0].Path)
+	assert.Equal(t, "🚀", ops[0].Args)
+	assert.Equal(t, []string{"format", "page_icon"}, ops[1].Path)
+	assert.Equal(t, "https://example.com/icon.png", ops[1].Args)
+	assert.Equal(t, []string{"format", "page_cover"}, ops[2].Path)
+	assert.Equal(t, "https://example.com/cover.jpg", ops[2].Args)
+
+	err = client.SetPageCover("not-an-id", "https://example.com/cover.jpg")
+	assert.Error(t, err)
+	assert.Len(t, ops, 3)
+}
@@ -54,7 +54,7 @@ func htmlFileName(title string) string {
 
 // HTMLFileNameForPage returns file name for html file
 func HTMLFileNameForPage(page *notionapi.Page) string {
-	return htmlFileName(page.Root().Title)
+	return htmlFileName(page.Title())
 }
 func log(format string, args ...interface{}) {
 	notionapi.Log(format, args...)
@@ -33,6 +33,17 @@ body {
 	white-space: pre-wrap;
 }
 
+@media only screen {
+	body:has(> .page-full-width) {
+		max-width: none;
+		margin: 2em 96px;
+	}
+}
+
+.page-small-text {
+	font-size: 14px;
+}
+
 a,
 a.visited {
 	color: inherit;
@@ -87,6 +98,12 @@ h3 {
 	padding: 1rem;
 }
 
+.callout-icon {
+	border-radius: 3px;
+	padding: 0 0.2em;
+	align-self: flex-start;
+}
+
 figure {
 	margin: 1.25em 0;
 	page-break-inside: avoid;
@@ -106,7 +123,8 @@ mark {
 	padding-left: 1.5em;
 }
 
-hr {
+hr,
+.notion-divider {
 	background: transparent;
 	display: block;
 	width: 100%;
@@ -343,7 +361,9 @@ img.icon {
 	border-radius: 3px;
 }
 
-.link-to-page {
+.link-to-page,
+.mention-page,
+.notion-sub-page {
 	margin: 1em 0;
 	padding: 0;
 	border: none;
@@ -354,6 +374,125 @@ p > .user {
 	opacity: 0.5;
 }
 
+.notion-reminder {
+	margin-left: 0.5em;
+	opacity: 0.5;
+}
+
+.code-wrapper {
+	position: relative;
+}
+
+.code-copy {
+	position: absolute;
+	top: 0.5em;
+	right: 0.5em;
+	font-size: 0.75em;
+	opacity: 0.6;
+}
+
+.notion-page-mention-icon {
+	margin-right: 0.25em;
+}
+
+img.notion-page-mention-icon {
+	width: 1em;
+	height: 1em;
+	vertical-align: -0.1em;
+}
+
+.notion-lock-indicator {
+	margin-left: 0.5em;
+	font-size: 0.6em;
+	opacity: 0.5;
+	vertical-align: middle;
+}
+
+.notion-file-preview {
+	display: block;
+	max-width: 100%;
+	margin-bottom: 0.5em;
+}
+
+video.notion-video {
+	display: block;
+	width: 100%;
+	height: auto;
+	margin-bottom: 0.5em;
+	background: black;
+}
+
+object.notion-file-preview {
+	width: 100%;
+	height: 600px;
+}
+
+.notion-synced-from {
+	font-size: 0.8em;
+	color: rgba(55, 53, 47, 0.6);
+}
+
+.admonition {
+	margin: 1em 0;
+	padding: 0.5em 1em;
+	border-left: 4px solid rgba(55, 53, 47, 0.4);
+	background: rgba(241, 241, 239, 1);
+}
+
+.admonition-title {
+	margin: 0 0 0.25em 0;
+	font-weight: 600;
+}
+
+.admonition-tip {
+	border-left-color: rgba(68, 131, 97, 1);
+}
+
+.admonition-note {
+	border-left-color: rgba(51, 126, 169, 1);
+}
+
+.admonition-warning {
+	border-left-color: rgba(203, 145, 47, 1);
+}
+
+.admonition-important,
+.admonition-danger {
+	border-left-color: rgba(212, 76, 71, 1);
+}
+
+img.emoji {
+	width: 1em;
+	height: 1em;
+	vertical-align: -0.1em;
+}
+
+.notion-collection-group-header th {
+	text-align: left;
+	padding-top: 1em;
+}
+
+.notion-collection-group-count {
+	color: rgba(55, 53, 47, 0.4);
+	font-weight: normal;
+	margin-left: 0.5em;
+}
+
+.notion-page-properties {
+	display: grid;
+	grid-template-columns: max-content auto;
+	gap: 0.25em 1em;
+	margin: 1em 0;
+}
+
+.notion-page-properties dt {
+	color: rgba(55, 53, 47, 0.6);
+}
+
+.notion-page-properties dd {
+	margin: 0;
+}
+
 td > .user,
 td > time {
 	white-space: nowrap;
@@ -403,6 +542,11 @@ code {
 	color: inherit;
 }
 
+.code-wrap {
+	white-space: pre-wrap;
+	word-break: break-word;
+}
+
 blockquote {
 	font-size: 1.25em;
 	margin: 1em 0;
@@ -410,11 +554,72 @@ blockquote {
 	border-left: 3px solid rgb(55, 53, 47);
 }
 
+blockquote.quote-large {
+	font-size: 1.875em;
+}
+
 .bookmark-href {
 	font-size: 0.75em;
 	opacity: 0.5;
 }
 
+a.bookmark {
+	text-decoration: none;
+	max-height: 8em;
+	padding: 0;
+	display: flex;
+	width: 100%;
+	align-items: stretch;
+}
+
+.bookmark-info {
+	flex: 4 1 180px;
+	padding: 12px 14px 14px;
+	display: flex;
+	flex-direction: column;
+	justify-content: space-between;
+}
+
+.bookmark-text {
+	display: flex;
+	flex-direction: column;
+}
+
+.bookmark-title {
+	font-size: 0.85em;
+	overflow: hidden;
+	text-overflow: ellipsis;
+	height: 1.75em;
+	white-space: nowrap;
+}
+
+.bookmark-description {
+	color: rgba(55, 53, 47, 0.6);
+	font-size: 0.75em;
+	overflow: hidden;
+	max-height: 4.5em;
+	word-break: break-word;
+}
+
+.bookmark-image {
+	width: 33%;
+	flex: 1 1 180px;
+	display: block;
+	position: relative;
+	object-fit: cover;
+	border-radius: 1px;
+}
+
+.notion-file-icon {
+	margin-right: 0.5em;
+}
+
+.notion-file-size {
+	margin-left: 0.5em;
+	font-size: 0.75em;
+	opacity: 0.5;
+}
+
 .sans { font-family: -apple-system, BlinkMacSystemFont, "Segoe UI", Helvetica, "Apple Color Emoji", Arial, sans-serif, "Segoe UI Emoji", "Segoe UI Symbol"; }
 .code { font-family: 'SFMono-Regular', Consolas, 'Liberation Mono', Menlo, Courier, monospace; }
 .serif { font-family: Lyon-Text, Georgia, KaiTi, STKaiTi, '华文楷体', KaiTi_GB2312, '楷体_GB2312', serif; }
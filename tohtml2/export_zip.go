@@ -0,0 +1,55 @@
+package tohtml2
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/kjk/notionapi"
+)
+
+// ExportPageZip writes a zip with page rendered to HTML and assets
+// (images, files etc.) it refers to, downloaded with client. Like
+// Notion's export, assets are stored in a directory next to the .html
+// file and the HTML links to them with relative paths.
+// If an error is returned, what was written to w is an incomplete
+// archive and should be discarded
+func ExportPageZip(client *notionapi.Client, page *notionapi.Page, w io.Writer) error {
+	c := NewConverter(page)
+	c.FullHTML = true
+	html, err := c.ToHTML()
+	if err != nil {
+		return err
+	}
+
+	zw := zip.NewWriter(w)
+	fw, err := zw.Create(filePathForPage(page.Root()))
+	if err != nil {
+		return err
+	}
+	if _, err = fw.Write(html); err != nil {
+		return err
+	}
+
+	var names []string
+	for name := range c.LocalizedAssets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		uri := c.LocalizedAssets[name]
+		rsp, err := client.DownloadFile(uri)
+		if err != nil {
+			return fmt.Errorf("DownloadFile('%s') failed with %s", uri, err)
+		}
+		fw, err := zw.Create(name)
+		if err != nil {
+			return err
+		}
+		if _, err = fw.Write(rsp.Data); err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
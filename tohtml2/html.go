@@ -4,11 +4,17 @@ import (
 	"bytes"
 	"fmt"
 	"html"
+	"net/url"
 	"os"
 	"os/exec"
 
 	"path"
+	"regexp"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
+	"unicode"
 
 	"github.com/kjk/notionapi"
 )
@@ -55,32 +61,71 @@ func safeName(s string) string {
 	return res
 }
 
+// normalizeNotionURL converts relative Notion paths like
+// /images/page-cover/gradients_11.jpg to absolute urls
+func normalizeNotionURL(uri string) string {
+	if strings.HasPrefix(uri, "/") && !strings.HasPrefix(uri, "//") {
+		return "https://www.notion.so" + uri
+	}
+	return uri
+}
+
 func fileNameFromPageCoverURL(uri string) string {
 	parts := strings.Split(uri, "/")
 	lastIdx := len(parts) - 1
 	return parts[lastIdx]
 }
 
-func filePathFromPageCoverURL(uri string, block *notionapi.Block) string {
-	// TODO: not sure about this heuristic. Maybe turn it into a whitelist:
-	// if starts with notion.so or aws, then download and convert to local
-	// otherwise leave alone
+// DefaultShouldLocalizeAsset is used if Converter.ShouldLocalizeAsset
+// is not set. Only files uploaded to Notion are localized
+func DefaultShouldLocalizeAsset(uri string) bool {
+	return strings.Contains(uri, "secure.notion-static.com") ||
+		strings.HasPrefix(uri, "https://s3-us-west-2.amazonaws.com/")
+}
+
+// matches how Notion's export decides which covers to include
+func notionCompatShouldLocalizeCover(uri string) bool {
 	if strings.HasPrefix(uri, "https://cdn.dutchcowboys.nl/uploads") {
-		return uri
+		return false
 	}
 	if strings.HasPrefix(uri, "https://images.unsplash.com") {
-		return uri
+		return false
 	}
 	if strings.HasPrefix(uri, "https://www.notion.so/images/") {
-		return uri
+		return false
 	}
-	if strings.HasPrefix(uri, "/images/page-cover/") {
-		return "https://www.notion.so" + uri
+	return true
+}
+
+func (c *Converter) shouldLocalizeAsset(uri string) bool {
+	if c.ShouldLocalizeAsset != nil {
+		return c.ShouldLocalizeAsset(uri)
+	}
+	return DefaultShouldLocalizeAsset(uri)
+}
+
+func (c *Converter) filePathFromPageCoverURL(uri string, block *notionapi.Block) string {
+	uri = normalizeNotionURL(uri)
+	shouldLocalize := c.shouldLocalizeAsset(uri)
+	if c.ShouldLocalizeAsset == nil && c.NotionCompat {
+		shouldLocalize = notionCompatShouldLocalizeCover(uri)
+	}
+	if !shouldLocalize {
+		return uri
 	}
 	fileName := fileNameFromPageCoverURL(uri)
 	// TODO: probably need to build mulitple dirs
 	dir := safeName(block.Title)
-	return path.Join(dir, fileName)
+	name := path.Join(dir, fileName)
+	c.addLocalizedAsset(name, uri)
+	return name
+}
+
+func (c *Converter) addLocalizedAsset(name string, uri string) {
+	if c.LocalizedAssets == nil {
+		c.LocalizedAssets = map[string]string{}
+	}
+	c.LocalizedAssets[name] = uri
 }
 
 func filePathForPage(block *notionapi.Block) string {
@@ -102,7 +147,7 @@ func urlBaseName(uri string) string {
 
 func filePathForCollection(page *notionapi.Page, col *notionapi.Collection) string {
 	name := safeName(col.Name()) + ".html"
-	name = safeName(page.Root().Title) + "/" + name
+	name = safeName(page.Title()) + "/" + name
 	return name
 }
 
@@ -118,11 +163,7 @@ func getTitleColDownloadedURL(row *notionapi.Block, block *notionapi.Block, col
 		title = "Untitled"
 	}
 	name := safeName(title) + ".html"
-	colName := col.Name()
-	if colName == "" {
-		colName = "Untitled Database"
-	}
-	name = safeName(colName) + "/" + name
+	name = safeName(col.Name()) + "/" + name
 	for block.Parent != nil {
 		block = block.Parent
 		if block.Type != notionapi.BlockPage {
@@ -136,16 +177,25 @@ func getTitleColDownloadedURL(row *notionapi.Block, block *notionapi.Block, col
 func getCollectionDownloadedFileName(page *notionapi.Page, col *notionapi.Collection, uri string) string {
 	name := urlBaseName(uri)
 	name = safeName(col.Name()) + "/" + name
-	name = safeName(page.Root().Title) + "/" + name
+	name = safeName(page.Title()) + "/" + name
 	return name
 }
 
-func getDownloadedFileName(uri string, block *notionapi.Block) string {
-	shouldDownload := false
-	if strings.HasPrefix(uri, "https://s3-us-west-2.amazonaws.com/secure.notion-static.com/") {
-		shouldDownload = true
+// collectionIconFileName returns local path of collection's icon. Icons
+// that are urls are recorded in LocalizedAssets
+func (c *Converter) collectionIconFileName(col *notionapi.Collection, uri string) string {
+	name := getCollectionDownloadedFileName(c.Page, col, uri)
+	if isURL(uri) {
+		c.addLocalizedAsset(name, normalizeNotionURL(uri))
 	}
-	if !shouldDownload {
+	return name
+}
+
+// getDownloadedFileName returns local path of a file if it should be
+// localized (see ShouldLocalizeAsset) or its url
+func (c *Converter) getDownloadedFileName(uri string, block *notionapi.Block) string {
+	uri = normalizeNotionURL(uri)
+	if !c.shouldLocalizeAsset(uri) {
 		return uri
 	}
 	name := urlBaseName(uri)
@@ -172,14 +222,15 @@ func getDownloadedFileName(uri string, block *notionapi.Block) string {
 	for strings.Contains(name, "//") {
 		name = strings.Replace(name, "//", "/", -1)
 	}
+	c.addLocalizedAsset(name, uri)
 	return name
 }
 
-func getFileOrSourceURL(block *notionapi.Block) string {
+func (c *Converter) getFileOrSourceURL(block *notionapi.Block) string {
 	if len(block.FileIDs) > 0 {
-		return getDownloadedFileName(block.Source, block)
+		return c.getDownloadedFileName(block.Source, block)
 	}
-	return block.Source
+	return normalizeNotionURL(block.Source)
 }
 
 func htmlFileName(title string) string {
@@ -189,7 +240,7 @@ func htmlFileName(title string) string {
 
 // HTMLFileNameForPage returns file name for html file
 func HTMLFileNameForPage(page *notionapi.Page) string {
-	return htmlFileName(page.Root().Title)
+	return htmlFileName(page.Title())
 }
 func log(format string, args ...interface{}) {
 	notionapi.Log(format, args...)
@@ -228,10 +279,24 @@ type Converter struct {
 	// to h1/h2/h3
 	AddHeaderAnchor bool
 
+	// if true, headers get ids derived from their text (e.g. "my-section")
+	// instead of Notion ids, so that links to them are readable. Links to
+	// headers of this page (table of contents, links to a Notion url with
+	// #${block id}) use the slugs. Other blocks keep Notion ids
+	SlugIDs bool
+
 	// allows over-riding rendering of specific blocks
 	// return false for default rendering
 	RenderBlockOverride BlockRenderFunc
 
+	// custom renderers for block types, checked after RenderBlockOverride
+	// and used instead of DefaultRenderFunc for a given block.Type
+	BlockRenderers map[string]func(*notionapi.Block)
+
+	// if set, it's called with time it took to render each block
+	// (including its children), to find slow blocks
+	BlockTimer func(blockType string, d time.Duration)
+
 	// RewriteURL allows re-writing URLs e.g. to convert inter-notion URLs
 	// to destination URLs
 	RewriteURL func(url string) string
@@ -240,6 +305,122 @@ type Converter struct {
 	// otherwise it's just the inner part going inside the body
 	FullHTML bool
 
+	// ClassPrefix is added to all class names we emit (e.g. with "nt-"
+	// "bulleted-list" becomes "nt-bulleted-list") to avoid clashes with
+	// CSS of the site. Built-in CSS doesn't use the prefix.
+	// Classes returned by LanguageClass are not prefixed
+	ClassPrefix string
+
+	// if true, the output is minified: empty class and style attributes
+	// are removed and whitespace between tags is collapsed. Content of
+	// <pre> and <textarea> is not changed
+	Minify bool
+
+	// if true, only renders blocks of the page, without <article>
+	// wrapper and page header. Ignored if FullHTML is true
+	BodyOnly bool
+
+	// if true, <figcaption> of images, files, embeds etc. gets an id
+	// and <figure> references it with aria-describedby
+	AccessibleCaptions bool
+
+	// tag of the element wrapping the page, "article" if empty.
+	// Must be one of "article", "main", "section" or "div"
+	RootTag string
+
+	// if true, mentions of pages in Pages link to their html file
+	// (see HTMLFileNameForPage) instead of their notion.so url.
+	// RewriteURL is not called for those links
+	LinkPagesRelatively bool
+
+	// if true, content of sub-pages is rendered inline instead
+	// of a link to sub-page. Sub-pages must be provided in Pages
+	InlineSubPages bool
+
+	// LanguageClass returns a class for <code> element of a code block
+	// given Notion's name of the language (e.g. "C++"). Return empty
+	// string for no class. By default it's DefaultLanguageClass, which
+	// follows Prism (https://prismjs.com) conventions
+	LanguageClass func(notionLang string) string
+
+	// if true, reminders set on dates are shown after the date
+	// e.g. "1 day before at 09:00"
+	ShowReminders bool
+
+	// html element used for BlockDivider, "hr" if empty. Other
+	// elements (e.g. "div") are rendered as <div class="notion-divider"></div>.
+	// Must be one of "hr", "div", "span" or "section"
+	DividerTag string
+
+	// if true, code blocks get a "Copy" <button class="code-copy"> with
+	// data-clipboard-target="#${id of <pre>}". Copying must be
+	// implemented in JavaScript by the caller
+	CodeCopyButton bool
+
+	// if true, elements of blocks get data-block-id="${block id}"
+	// attribute, in addition to id (which might be a slug, see SlugIDs)
+	DataBlockID bool
+
+	// if true, to-do items are rendered with <input type="checkbox">
+	// (with data-block-id of the to-do) instead of a <div> styled as
+	// a checkbox
+	InteractiveTodos bool
+
+	// if not empty, only blocks of those types (e.g. notionapi.BlockText)
+	// are rendered. Children of blocks that are not rendered are still
+	// rendered if they match (e.g. text inside a toggle), except for
+	// content of sub-pages which is skipped with the sub-page
+	IncludeBlockTypes []string
+
+	// blocks of those types are not rendered, including their children.
+	// Takes precedence over IncludeBlockTypes. The page being rendered
+	// is never skipped
+	ExcludeBlockTypes []string
+
+	// ShouldLocalizeAsset decides if a file (image, page cover, file
+	// attachment) is referenced by its local path, which assumes that the
+	// caller downloads it, or by its url. Default is DefaultShouldLocalizeAsset
+	ShouldLocalizeAsset func(uri string) bool
+
+	// if true, properties of a page that is an item in a collection
+	// (database) are shown below page title as <dl>
+	ShowPageProperties bool
+
+	// if set, emoji icons of pages and callouts are rendered as
+	// <img class="emoji" src="${TwemojiBaseURL}/${codepoints}.svg"/>
+	// e.g. "https://cdn.jsdelivr.net/gh/twitter/twemoji@14.0.2/assets/svg"
+	// for consistent look across platforms
+	TwemojiBaseURL string
+
+	// if true, BlockFile with a pdf file embeds it with <object>, in
+	// addition to a link. Image files are always shown as a thumbnail
+	EmbedPDFs bool
+
+	// by default line breaks in text (shift+enter in Notion) are
+	// rendered as <br/>. If true they're kept as "\n", like in Notion's
+	// HTML export, which browsers show as a space
+	PreserveLineBreaks bool
+
+	// if true, callouts with 💡, ⚠️, ℹ️ etc. icon are rendered as
+	// <div class="admonition admonition-tip"> (or -warning, -note,
+	// -important, -danger) with a title, like admonitions in docs
+	CalloutAdmonitions bool
+
+	// if true, copies of synced blocks have a "Synced from ${page}"
+	// label with a link to the page with the original block
+	ShowSyncedFrom bool
+
+	// if true, a lock icon is shown in the header of locked pages
+	ShowLockIndicator bool
+
+	// if true, comments are removed from text before rendering so that
+	// commented text is rendered the same as the text around it
+	DropComments bool
+
+	// if true, all toggles are rendered expanded. By default we use
+	// collapsed / expanded state of the toggle from Notion
+	ExpandAllToggles bool
+
 	// we need this to properly render ordered and numbered lists
 	CurrBlocks   []*notionapi.Block
 	CurrBlockIdx int
@@ -249,12 +430,32 @@ type Converter struct {
 	Pages    []*notionapi.Page
 	idToPage map[string]*notionapi.Page
 
+	// if provided (e.g. from notionapi.Client.GetBacklinks), a
+	// "Linked references" section with links to pages that link to
+	// this page is added at the end of the page body
+	Backlinks []*notionapi.Backlink
+
+	// ids of pages that are mentioned in the page but are neither in Page
+	// nor in Pages, set by ToHTML. A site generator can download and render
+	// them
+	UnresolvedRefs []string
+
+	// local paths of assets (e.g. images uploaded to Notion) the page
+	// refers to, mapped to their urls. Set by ToHTML. A caller can
+	// download them next to the html file (see ExportPageZip)
+	LocalizedAssets map[string]string
+
 	// data provided by they caller, useful when providing
 	// RenderBlockOverride
 	Data interface{}
 
 	didImportKatexCSS bool
 	bufs              []*bytes.Buffer
+	// ids of pages already rendered inline, to avoid infinite recursion
+	inlinedPages map[string]bool
+	// for SlugIDs, maps block id to its slug and tracks used slugs
+	blockIDToSlug map[string]string
+	usedSlugs     map[string]bool
 }
 
 // NewConverter returns customizable HTML renderer
@@ -264,6 +465,63 @@ func NewConverter(page *notionapi.Page) *Converter {
 	}
 }
 
+// cls returns space-separated class names with ClassPrefix applied
+// to each of them
+func (c *Converter) cls(names string) string {
+	if c.ClassPrefix == "" {
+		return names
+	}
+	parts := strings.Fields(names)
+	for i, name := range parts {
+		parts[i] = c.ClassPrefix + name
+	}
+	return strings.Join(parts, " ")
+}
+
+// classAttr returns ` class="names"` attribute (with ClassPrefix applied)
+// or empty string if there are no class names. Notion emits empty
+// class attributes so we keep them in NotionCompat mode
+func (c *Converter) classAttr(names string) string {
+	names = c.cls(strings.TrimSpace(names))
+	if names == "" && !c.NotionCompat {
+		return ""
+	}
+	return fmt.Sprintf(` class="%s"`, names)
+}
+
+// Reset clears the state left after rendering a page and sets page as
+// the page to render. Configuration (e.g. NotionCompat) is not changed so
+// the same Converter can be used to render multiple pages
+func (c *Converter) Reset(page *notionapi.Page) {
+	c.Page = page
+	// backlinks are for a specific page
+	c.Backlinks = nil
+	c.UnresolvedRefs = nil
+	c.LocalizedAssets = nil
+	c.Buf = nil
+	c.ListNo = 0
+	c.CurrBlocks = nil
+	c.CurrBlockIdx = 0
+	c.idToPage = nil
+	c.didImportKatexCSS = false
+	c.bufs = nil
+	c.inlinedPages = nil
+	c.blockIDToSlug = nil
+	c.usedSlugs = nil
+}
+
+// addUnresolvedRef records id of a page that is referenced but
+// not loaded
+func (c *Converter) addUnresolvedRef(id string) {
+	id = notionapi.ToDashID(id)
+	for _, s := range c.UnresolvedRefs {
+		if s == id {
+			return
+		}
+	}
+	c.UnresolvedRefs = append(c.UnresolvedRefs, id)
+}
+
 // PageByID returns Page given its ID
 func (c *Converter) PageByID(pageID string) *notionapi.Page {
 	if len(c.Pages) == 0 {
@@ -311,6 +569,7 @@ func (c *Converter) A(uri, text, cls string) {
 	// at best should only encoede as url
 	uri = EscapeHTML(uri)
 	text = EscapeHTML(text)
+	cls = c.cls(cls)
 	if cls != "" {
 		cls = fmt.Sprintf(` class="%s"`, cls)
 	}
@@ -360,8 +619,54 @@ func (c *Converter) IsNextBlockOfType(t string) bool {
 // FormatDate formats the data
 func (c *Converter) FormatDate(d *notionapi.Date) string {
 	// TODO: allow over-riding date formatting
-	s := notionapi.FormatDate(d)
-	return fmt.Sprintf(`<time>@%s</time>`, s)
+	s := EscapeHTML(notionapi.FormatDate(d))
+	if c.NotionCompat {
+		return fmt.Sprintf(`<time>@%s</time>`, s)
+	}
+	res := fmt.Sprintf(`<time datetime="%s">@%s</time>`, EscapeHTML(notionapi.FormatDateISO(d)), s)
+	if c.ShowReminders && d.Reminder != nil {
+		res += fmt.Sprintf(`<span class="%s">⏰ %s</span>`, c.cls("notion-reminder"), EscapeHTML(formatReminder(d.Reminder)))
+	}
+	return res
+}
+
+// formatReminder returns description of a reminder e.g. "1 day before at 09:00"
+func formatReminder(r *notionapi.Reminder) string {
+	var s string
+	switch {
+	case r.Value == 0 && r.Unit == "day":
+		s = "on day of event"
+	case r.Value == 0:
+		s = "at time of event"
+	case r.Value == 1:
+		s = fmt.Sprintf("1 %s before", r.Unit)
+	default:
+		s = fmt.Sprintf("%d %ss before", r.Value, r.Unit)
+	}
+	if r.Time != "" {
+		s += " at " + r.Time
+	}
+	return s
+}
+
+// renderLinkMention returns html for a rich mention of a url. If we don't
+// have a title, it's a plain link
+func (c *Converter) renderLinkMention(lm *notionapi.LinkMention) string {
+	uri := lm.Href
+	if c.RewriteURL != nil {
+		uri = c.RewriteURL(uri)
+	}
+	uri = EscapeHTML(uri)
+	if lm.Title == "" {
+		return fmt.Sprintf(`<a href="%s">%s</a>`, uri, uri)
+	}
+	s := fmt.Sprintf(`<span class="%s"><a href="%s">`, c.cls("notion-link-mention"), uri)
+	if lm.IconURL != "" {
+		s += fmt.Sprintf(`<img class="%s" src="%s"/>`, c.cls("icon"), EscapeHTML(lm.IconURL))
+	}
+	s += EscapeHTML(lm.Title)
+	s += `</a></span>`
+	return s
 }
 
 // RenderInline renders inline block
@@ -374,7 +679,7 @@ func (c *Converter) RenderInline(b *notionapi.TextSpan) {
 		case notionapi.AttrHighlight:
 			// TODO: possibly needs to change b.Highlight
 			hl := notionapi.AttrGetHighlight(attr)
-			start += fmt.Sprintf(`<mark class="highlight-%s">`, hl)
+			start += fmt.Sprintf(`<mark class="%s">`, c.cls("highlight-"+hl))
 			close = `</mark>` + close
 		case notionapi.AttrBold:
 			start += `<strong>`
@@ -386,30 +691,54 @@ func (c *Converter) RenderInline(b *notionapi.TextSpan) {
 			start += `<del>`
 			close = `</del>` + close
 		case notionapi.AttrCode:
-			start += `<code>`
+			// distinguish from <code> of code blocks
+			if c.NotionCompat {
+				start += `<code>`
+			} else {
+				start += fmt.Sprintf(`<code class="%s">`, c.cls("notion-inline-code"))
+			}
 			close = `</code>` + close
 		case notionapi.AttrPage:
 			pageID := notionapi.AttrGetPageID(attr)
 			pageTitle := ""
-			relURL := notionapi.ToNoDashID(pageID)
 			block := c.Page.BlockByID(pageID)
-			if block != nil {
-				pageTitle = block.Title
+			if block == nil && c.LinkPagesRelatively {
+				// a page we render together with this page
+				if linkedPage := c.PageByID(pageID); linkedPage != nil {
+					block = linkedPage.Root()
+				}
 			}
-			if pageTitle != "" {
-				urlName := safeName(pageTitle)
-				urlName = strings.Replace(urlName, " ", "-", -1)
-				relURL = urlName + "-" + relURL
+			if block == nil && c.PageByID(pageID) == nil {
+				c.addUnresolvedRef(pageID)
 			}
-			uri := "https://www.notion.so/" + relURL
-			if c.RewriteURL != nil {
-				uri = c.RewriteURL(uri)
+			// a database, its title is the name of the collection
+			var col *notionapi.Collection
+			if block != nil && block.Type == notionapi.BlockCollectionViewPage {
+				colPage := c.Page
+				if block.Page != nil {
+					colPage = block.Page
+				}
+				col = colPage.CollectionByID(block.CollectionID)
+			}
+			if col != nil {
+				pageTitle = col.Name()
+			} else if block != nil {
+				pageTitle = block.Title
 			}
-			start += fmt.Sprintf(`<a href="%s">%s</a>`, uri, EscapeHTML(pageTitle))
+			uri := c.pageURL(pageID, pageTitle)
+			icon := ""
+			if col != nil && !c.NotionCompat {
+				icon = c.collectionMentionIcon(col)
+			} else if block != nil && !c.NotionCompat {
+				icon = c.pageMentionIcon(block)
+			}
+			start += fmt.Sprintf(`<a href="%s">%s%s</a>`, uri, icon, EscapeHTML(pageTitle))
 			text = ""
 		case notionapi.AttrLink:
 			uri := notionapi.AttrGetLink(attr)
-			if c.RewriteURL != nil {
+			if anchor := c.blockLinkAnchor(uri); anchor != "" {
+				uri = anchor
+			} else if c.RewriteURL != nil {
 				uri = c.RewriteURL(uri)
 			}
 			if uri == "" {
@@ -420,23 +749,163 @@ func (c *Converter) RenderInline(b *notionapi.TextSpan) {
 				start += fmt.Sprintf(`<a href="%s">`, uri)
 			}
 			close = `</a>` + close
+		case notionapi.AttrLinkMention:
+			start += c.renderLinkMention(notionapi.AttrGetLinkMention(attr))
+			text = ""
+		case notionapi.AttrExternalObject:
+			// the data of external object (its title, url etc.) lives in
+			// a record we don't load. Usually the text is just "‣", a
+			// placeholder for the mention, which is meaningless on its own
+			if text == "‣" {
+				text = ""
+			} else {
+				start += fmt.Sprintf(`<span class="%s">`, c.cls("notion-external-object"))
+				close = `</span>` + close
+			}
 		case notionapi.AttrUser:
 			userID := notionapi.AttrGetUserID(attr)
 			userName := notionapi.ResolveUser(c.Page, userID)
-			start += fmt.Sprintf(`<span class="user">@%s</span>`, userName)
+			start += fmt.Sprintf(`<span class="%s">@%s</span>`, c.cls("user"), userName)
 			text = ""
 		case notionapi.AttrDate:
 			date := notionapi.AttrGetDate(attr)
 			start += c.FormatDate(date)
 			text = ""
+		case notionapi.AttrEquation:
+			start += c.renderInlineEquation(notionapi.AttrGetEquation(attr))
+			text = ""
+		}
+	}
+	text = EscapeHTML(text)
+	// browsers collapse newlines to spaces
+	if !c.PreserveLineBreaks && !c.NotionCompat {
+		text = strings.Replace(text, "\n", "<br/>", -1)
+	}
+	c.Printf(start + text + close)
+}
+
+// twemojiCodepoints returns name of Twemoji image for emoji e.g. "1f44d-1f3fd"
+// for 👍🏽. Like Twemoji, we drop variation selector U+FE0F unless the
+// emoji is a sequence joined with U+200D
+func twemojiCodepoints(emoji string) string {
+	if !strings.ContainsRune(emoji, '\u200d') {
+		emoji = strings.Replace(emoji, "\ufe0f", "", -1)
+	}
+	var parts []string
+	for _, r := range emoji {
+		parts = append(parts, strconv.FormatInt(int64(r), 16))
+	}
+	return strings.Join(parts, "-")
+}
+
+// emojiHTML returns html for emoji used as an icon. It's the emoji
+// itself or <img> with Twemoji image if TwemojiBaseURL is set
+func (c *Converter) emojiHTML(emoji string) string {
+	if c.TwemojiBaseURL == "" || emoji == "" {
+		return EscapeHTML(emoji)
+	}
+	uri := strings.TrimSuffix(c.TwemojiBaseURL, "/") + "/" + twemojiCodepoints(emoji) + ".svg"
+	return fmt.Sprintf(`<img class="%s" alt="%s" src="%s"/>`, c.cls("emoji"), EscapeHTML(emoji), EscapeHTML(uri))
+}
+
+// pageMentionIcon returns html for the icon (emoji or image) of a mentioned
+// page, shown before its title. Empty string if the page has no icon
+func (c *Converter) pageMentionIcon(block *notionapi.Block) string {
+	pageIcon, _ := block.PropAsString("format.page_icon")
+	if pageIcon == "" {
+		return ""
+	}
+	if isURL(pageIcon) {
+		fileName := c.getDownloadedFileName(pageIcon, block)
+		return fmt.Sprintf(`<img class="%s" src="%s"/>`, c.cls("icon notion-page-mention-icon"), fileName)
+	}
+	return fmt.Sprintf(`<span class="%s">%s</span>`, c.cls("icon notion-page-mention-icon"), c.emojiHTML(pageIcon))
+}
+
+func (c *Converter) collectionMentionIcon(col *notionapi.Collection) string {
+	if col.Icon == "" {
+		return ""
+	}
+	if isURL(col.Icon) {
+		fileName := c.collectionIconFileName(col, col.Icon)
+		return fmt.Sprintf(`<img class="%s" src="%s"/>`, c.cls("icon notion-page-mention-icon"), fileName)
+	}
+	return fmt.Sprintf(`<span class="%s">%s</span>`, c.cls("icon notion-page-mention-icon"), c.emojiHTML(col.Icon))
+}
+
+// attributes like @user or @page replace the text of the span so spans
+// with them can't be merged
+func isTextReplacingAttr(attr notionapi.TextAttr) bool {
+	switch notionapi.AttrGetType(attr) {
+	case notionapi.AttrPage, notionapi.AttrUser, notionapi.AttrDate,
+		notionapi.AttrLinkMention, notionapi.AttrExternalObject,
+		notionapi.AttrEquation:
+		return true
+	}
+	return false
+}
+
+func canMergeTextSpans(ts1, ts2 *notionapi.TextSpan) bool {
+	if len(ts1.Attrs) != len(ts2.Attrs) {
+		return false
+	}
+	for i, attr := range ts1.Attrs {
+		attr2 := ts2.Attrs[i]
+		if len(attr) != len(attr2) || isTextReplacingAttr(attr) {
+			return false
+		}
+		for j := range attr {
+			if attr[j] != attr2[j] {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// mergeTextSpans combines adjacent spans with identical attributes into
+// one span so that we don't emit e.g. <strong>a</strong><strong>b</strong>.
+// Doesn't modify blocks
+func mergeTextSpans(blocks []*notionapi.TextSpan) []*notionapi.TextSpan {
+	if len(blocks) < 2 {
+		return blocks
+	}
+	var res []*notionapi.TextSpan
+	for _, ts := range blocks {
+		n := len(res)
+		if n > 0 && canMergeTextSpans(res[n-1], ts) {
+			merged := *res[n-1]
+			merged.Text += ts.Text
+			res[n-1] = &merged
+			continue
+		}
+		res = append(res, ts)
+	}
+	return res
+}
+
+// dropComments returns copies of spans without AttrComment attributes.
+// Doesn't modify blocks
+func dropComments(blocks []*notionapi.TextSpan) []*notionapi.TextSpan {
+	res := make([]*notionapi.TextSpan, len(blocks))
+	for i, ts := range blocks {
+		var attrs []notionapi.TextAttr
+		for _, attr := range ts.Attrs {
+			if notionapi.AttrGetType(attr) != notionapi.AttrComment {
+				attrs = append(attrs, attr)
+			}
 		}
+		res[i] = &notionapi.TextSpan{Text: ts.Text, Attrs: attrs}
 	}
-	c.Printf(start + EscapeHTML(text) + close)
+	return res
 }
 
 // RenderInlines renders inline blocks
 func (c *Converter) RenderInlines(blocks []*notionapi.TextSpan) {
-	for _, block := range blocks {
+	if c.DropComments {
+		blocks = dropComments(blocks)
+	}
+	for _, block := range mergeTextSpans(blocks) {
 		c.RenderInline(block)
 	}
 }
@@ -448,21 +917,82 @@ func (c *Converter) GetInlineContent(blocks []*notionapi.TextSpan) string {
 		return ""
 	}
 	c.PushNewBuffer()
-	for _, block := range blocks {
-		c.RenderInline(block)
-	}
+	c.RenderInlines(blocks)
 	return c.PopBuffer().String()
 }
 
 // RenderCode renders BlockCode
 func (c *Converter) RenderCode(block *notionapi.Block) {
+	// caption and code wrapping are not in Notion's export
+	hasCaption := !c.NotionCompat && len(block.GetCaption()) > 0
+	if hasCaption {
+		c.Printf(`<figure class="%s"%s>`, c.cls("code-figure"), c.captionRefAttr(block))
+	}
+	if c.CodeCopyButton {
+		c.Printf(`<div class="%s">`, c.cls("code-wrapper"))
+		c.Printf(`<button type="button" class="%s" data-clipboard-target="#%s">Copy</button>`, c.cls("code-copy"), block.ID)
+	}
 	cls := "code"
-	c.Printf(`<pre id="%s" class="%s">`, block.ID, cls)
+	if !c.NotionCompat {
+		if wrap, _ := block.Prop("format.code_wrap"); wrap == true {
+			cls += " code-wrap"
+		}
+	}
+	c.Printf(`<pre id="%s" class="%s">`, block.ID, c.cls(cls))
 	{
 		code := EscapeHTML(block.Code)
-		c.Printf(`<code>%s</code>`, code)
+		codeCls := ""
+		if !c.NotionCompat {
+			langClass := c.LanguageClass
+			if langClass == nil {
+				langClass = DefaultLanguageClass
+			}
+			codeCls = langClass(block.CodeLanguage)
+		}
+		if codeCls == "" {
+			c.Printf(`<code>%s</code>`, code)
+		} else {
+			c.Printf(`<code class="%s">%s</code>`, EscapeHTML(codeCls), code)
+		}
 	}
 	c.Printf("</pre>")
+	if c.CodeCopyButton {
+		c.Printf(`</div>`)
+	}
+	if hasCaption {
+		c.RenderCaption(block)
+		c.Printf(`</figure>`)
+	}
+}
+
+// maps names of languages in Notion (lower-cased) to names used by Prism
+// when they're not the same
+var notionLangToPrism = map[string]string{
+	"c++":          "cpp",
+	"c#":           "csharp",
+	"f#":           "fsharp",
+	"objective-c":  "objectivec",
+	"shell":        "bash",
+	"html":         "markup",
+	"xml":          "markup",
+	"vb.net":       "vbnet",
+	"visual basic": "visual-basic",
+	"webassembly":  "wasm",
+	"plain text":   "",
+}
+
+// DefaultLanguageClass returns Prism's class (e.g. "language-cpp") for
+// Notion's name of a language (e.g. "C++")
+func DefaultLanguageClass(notionLang string) string {
+	lang := strings.ToLower(strings.TrimSpace(notionLang))
+	if s, ok := notionLangToPrism[lang]; ok {
+		lang = s
+	}
+	if lang == "" {
+		return ""
+	}
+	lang = strings.Replace(lang, " ", "-", -1)
+	return "language-" + lang
 }
 
 // EscapeHTML escapes HTML in the same way as Notion.
@@ -485,6 +1015,9 @@ func isURL(uri string) bool {
 	return false
 }
 
+// renderHeader renders cover, icon and title of the page. All of them
+// are optional: a page without a title gets an empty
+// <h1 class="page-title"></h1>, like in Notion's export
 func (c *Converter) renderHeader(block *notionapi.Block) {
 	c.Printf(`<header>`)
 	{
@@ -492,11 +1025,15 @@ func (c *Converter) renderHeader(block *notionapi.Block) {
 		// formatPage == nil happened in bf5d1c1f793a443ca4085cc99186d32f
 		pageCover, _ := block.PropAsString("format.page_cover")
 		if pageCover != "" {
-			position := (1 - formatPage.PageCoverPosition) * 100
-			coverURL := filePathFromPageCoverURL(pageCover, block)
+			coverPosition := 0.0
+			if formatPage != nil {
+				coverPosition = formatPage.PageCoverPosition
+			}
+			position := (1 - coverPosition) * 100
+			coverURL := c.filePathFromPageCoverURL(pageCover, block)
 			// TODO: Notion incorrectly escapes them
 			coverURL = EscapeHTML(coverURL)
-			c.Printf(`<img class="page-cover-image" src="%s" style="object-position:center %v%%"/>`, coverURL, position)
+			c.Printf(`<img class="%s" src="%s" style="object-position:center %v%%"/>`, c.cls("page-cover-image"), coverURL, position)
 		}
 		pageIcon, _ := block.PropAsString("format.page_icon")
 		if pageIcon != "" {
@@ -505,38 +1042,100 @@ func (c *Converter) renderHeader(block *notionapi.Block) {
 			if pageCover != "" {
 				clsCover = "page-header-icon-with-cover"
 			}
-			c.Printf(`<div class="page-header-icon %s">`, clsCover)
+			c.Printf(`<div class="%s">`, c.cls("page-header-icon "+clsCover))
 			if isURL(pageIcon) {
-				fileName := getDownloadedFileName(pageIcon, block)
-				c.Printf(`<img class="icon" src="%s"/>`, fileName)
+				fileName := c.getDownloadedFileName(pageIcon, block)
+				c.Printf(`<img class="%s" src="%s"/>`, c.cls("icon"), fileName)
 			} else {
-				c.Printf(`<span class="icon">%s</span>`, pageIcon)
+				c.Printf(`<span class="%s">%s</span>`, c.cls("icon"), c.emojiHTML(pageIcon))
 			}
 			c.Printf(`</div>`)
 		}
 
-		c.Printf(`<h1 class="page-title">`)
+		c.Printf(`<h1 class="%s">`, c.cls("page-title"))
 		{
 			c.RenderInlines(block.InlineContent)
+			if c.ShowLockIndicator && block.IsLocked() {
+				c.Printf(`<span class="%s" title="Locked">🔒</span>`, c.cls("notion-lock-indicator"))
+			}
 		}
 		c.Printf(`</h1>`)
+		if c.ShowPageProperties {
+			c.renderPageProperties(block)
+		}
 	}
 	c.Printf(`</header>`)
 }
 
+// pagePropertyIDs returns ids of properties of a collection shown on
+// a page of a collection item, in the order set in Notion. Title
+// is not included because it's shown as page title
+func pagePropertyIDs(col *notionapi.Collection) []string {
+	var res []string
+	if col.Format != nil && len(col.Format.CollectionPageProperties) > 0 {
+		for _, prop := range col.Format.CollectionPageProperties {
+			info := col.CollectionSchema[prop.Property]
+			if !prop.Visible || info == nil || info.Type == "title" {
+				continue
+			}
+			res = append(res, prop.Property)
+		}
+		return res
+	}
+	for id, info := range col.CollectionSchema {
+		if info != nil && info.Type != "title" {
+			res = append(res, id)
+		}
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return col.CollectionSchema[res[i]].Name < col.CollectionSchema[res[j]].Name
+	})
+	return res
+}
+
+// renderPageProperties renders properties (values of columns) of a page
+// that is an item in a collection (database)
+func (c *Converter) renderPageProperties(block *notionapi.Block) {
+	if block.ParentTable != notionapi.TableCollection {
+		return
+	}
+	col := c.Page.CollectionByID(block.ParentID)
+	if col == nil {
+		return
+	}
+	ids := pagePropertyIDs(col)
+	if len(ids) == 0 {
+		return
+	}
+	c.Printf(`<dl class="%s">`, c.cls("notion-page-properties"))
+	for _, id := range ids {
+		name := col.CollectionSchema[id].Name
+		c.Printf(`<dt>%s</dt>`, EscapeHTML(name))
+		c.Printf(`<dd>`)
+		c.RenderInlines(block.GetProperty(id))
+		c.Printf(`</dd>`)
+	}
+	c.Printf(`</dl>`)
+}
+
 // RenderCollectionViewPage renders BlockCollectionViewPage
 func (c *Converter) RenderCollectionViewPage(block *notionapi.Block) {
 	colID := block.CollectionID
 	col := c.Page.CollectionByID(colID)
+	if col == nil {
+		log("missing collection %s for block %s in page %s\n", colID, block.ID, notionapi.ToNoDashID(c.Page.ID))
+		c.Printf(`<div id="%s" class="%s"></div>`, block.ID, c.cls("collection-content missing"))
+		return
+	}
 	icon := col.Icon
 	name := col.Name()
-	c.Printf(`<figure id="%s" class="link-to-page">`, block.ID)
+	c.Printf(`<figure id="%s" class="%s">`, block.ID, c.cls("link-to-page"))
 	{
 		filePath := filePathForCollection(c.Page, col)
 		c.Printf(`<a href="%s">`, filePath)
 		{
-			uri := getCollectionDownloadedFileName(c.Page, col, icon)
-			c.Printf(`<img class="icon" src="%s"/>`, uri)
+			uri := c.collectionIconFileName(col, icon)
+			c.Printf(`<img class="%s" src="%s"/>`, c.cls("icon"), uri)
 		}
 		// TODO: should name be inlines?
 		c.Printf(`%s</a>`, name)
@@ -545,29 +1144,95 @@ func (c *Converter) RenderCollectionViewPage(block *notionapi.Block) {
 }
 
 func (c *Converter) renderLinkToPage(block *notionapi.Block) {
-	uri := filePathForPage(block)
-	cls := getBlockColorClass(block) + " link-to-page"
+	c.renderPageLink(block, "link-to-page")
+}
+
+// RenderAlias renders BlockAlias i.e. a "link to page" block
+func (c *Converter) RenderAlias(block *notionapi.Block) {
+	targetID := block.AliasPointerID()
+	target := c.Page.BlockByID(targetID)
+	if target == nil {
+		if page := c.PageByID(targetID); page != nil {
+			target = page.Root()
+		}
+	}
+	if target == nil {
+		// we don't know the title or the icon of the page
+		c.addUnresolvedRef(targetID)
+		target = &notionapi.Block{ID: targetID, Title: "Untitled"}
+	}
+	uri := ""
+	if c.Page.IsSubPage(target) {
+		uri = filePathForPage(target)
+	} else {
+		uri = c.pageURL(targetID, target.Title)
+	}
+	c.renderPageLinkTo(block, target, uri, "link-to-page")
+}
+
+// renderMentionPage renders a page block that is shown in this page
+// but lives in another page
+func (c *Converter) renderMentionPage(block *notionapi.Block) {
+	uri := c.pageURL(block.ID, block.Title)
+	c.renderPageLinkTo(block, block, uri, "mention-page")
+}
+
+// pageURL returns url of a page that isn't a part of this page. It's a
+// relative link if LinkPagesRelatively is set and the page is in Pages
+func (c *Converter) pageURL(pageID string, pageTitle string) string {
+	if c.LinkPagesRelatively {
+		if page := c.PageByID(pageID); page != nil {
+			return filePathForPage(page.Root())
+		}
+	}
+	relURL := notionapi.ToNoDashID(pageID)
+	if pageTitle != "" {
+		urlName := safeName(pageTitle)
+		urlName = strings.Replace(urlName, " ", "-", -1)
+		relURL = urlName + "-" + relURL
+	}
+	uri := "https://www.notion.so/" + relURL
+	if c.RewriteURL != nil {
+		uri = c.RewriteURL(uri)
+	}
+	return uri
+}
+
+// renderPageLink renders a link to a page, with page's icon (if it has one)
+func (c *Converter) renderPageLink(block *notionapi.Block, clsLink string) {
+	c.renderPageLinkTo(block, block, filePathForPage(block), clsLink)
+}
+
+// renderPageLinkTo renders block as a link to uri with title and icon
+// of target page
+func (c *Converter) renderPageLinkTo(block *notionapi.Block, target *notionapi.Block, uri string, clsLink string) {
+	cls := getBlockColorClass(block) + " " + clsLink
 	cls = cleanAttr(cls)
-	c.Printf(`<figure id="%s" class="%s">`, block.ID, cls)
+	c.Printf(`<figure id="%s" class="%s">`, block.ID, c.cls(cls))
 	{
 		c.Printf(`<a href="%s">`, uri)
-		pageIcon, ok := block.PropAsString("format.page_icon")
+		pageIcon, ok := target.PropAsString("format.page_icon")
 		if ok {
 			if isURL(pageIcon) {
-				fileName := getDownloadedFileName(pageIcon, block)
-				c.Printf(`<img class="icon" src="%s"/>`, fileName)
+				fileName := c.getDownloadedFileName(pageIcon, target)
+				c.Printf(`<img class="%s" src="%s"/>`, c.cls("icon"), fileName)
 			} else {
-				c.Printf(`<span class="icon">%s</span>`, pageIcon)
+				c.Printf(`<span class="%s">%s</span>`, c.cls("icon"), c.emojiHTML(pageIcon))
 			}
 		}
 		// TODO: possibly r.RenderInlines(block.InlineContent)
-		c.Printf(EscapeHTML(block.Title))
+		c.Printf(EscapeHTML(target.Title))
 		c.Printf(`</a>`)
 	}
 	c.Printf(`</figure>`)
 }
 
 func (c *Converter) renderRootPage(block *notionapi.Block) {
+	if c.BodyOnly && !c.FullHTML {
+		c.RenderChildren(block)
+		c.renderBacklinks()
+		return
+	}
 	if c.FullHTML {
 		c.Printf(`<html>`)
 		{
@@ -588,24 +1253,123 @@ func (c *Converter) renderRootPage(block *notionapi.Block) {
 		if fp.PageFont != "" {
 			clsFont = fp.PageFont
 		}
+		if !c.NotionCompat {
+			if fp.PageSmallText {
+				clsFont += " page-small-text"
+			}
+			if fp.PageFullWidth {
+				clsFont += " page-full-width"
+			}
+		}
+	}
+	rootTag := c.rootTag()
+	if c.DataBlockID {
+		c.Printf(`<%s id="%s" data-block-id="%s" class="%s">`, rootTag, block.ID, block.ID, c.cls("page "+clsFont))
+	} else {
+		c.Printf(`<%s id="%s" class="%s">`, rootTag, block.ID, c.cls("page "+clsFont))
 	}
-	c.Printf(`<article id="%s" class="page %s">`, block.ID, clsFont)
 	c.renderHeader(block)
 	{
-		c.Printf(`<div class="page-body">`)
+		c.Printf(`<div class="%s">`, c.cls("page-body"))
 		c.RenderChildren(block)
+		c.renderBacklinks()
 		c.Printf(`</div>`)
 	}
-	c.Printf(`</article>`)
+	c.Printf(`</%s>`, rootTag)
 
 	if c.FullHTML {
 		c.Printf(`</body></html>`)
 	}
 }
 
+var validRootTags = []string{"article", "main", "section", "div"}
+
+// rootTag returns a tag of the element wrapping the page
+func (c *Converter) rootTag() string {
+	if c.RootTag == "" || !hasString(validRootTags, c.RootTag) {
+		return "article"
+	}
+	return c.RootTag
+}
+
+// renderBacklinks renders links to pages in Backlinks. A page that
+// links multiple times is only shown once
+func (c *Converter) renderBacklinks() {
+	var pages []*notionapi.Block
+	seen := map[string]bool{}
+	for _, bl := range c.Backlinks {
+		if bl == nil || bl.Page == nil || seen[bl.PageID] {
+			continue
+		}
+		seen[bl.PageID] = true
+		pages = append(pages, bl.Page)
+	}
+	if len(pages) == 0 {
+		return
+	}
+	c.Printf(`<section class="%s">`, c.cls("notion-backlinks"))
+	{
+		c.Printf(`<h2 class="%s">Linked references</h2>`, c.cls("notion-backlinks-title"))
+		c.Printf(`<ul>`)
+		for _, page := range pages {
+			c.Printf(`<li>`)
+			uri := filePathForPage(page)
+			title := page.Title
+			if title == "" {
+				title = "Untitled"
+			}
+			c.A(uri, title, "")
+			c.Printf(`</li>`)
+		}
+		c.Printf(`</ul>`)
+	}
+	c.Printf(`</section>`)
+}
+
+// renderSubPageInline renders content of a sub-page inside the current
+// page. Returns false if the sub-page can't be rendered inline
+func (c *Converter) renderSubPageInline(block *notionapi.Block) bool {
+	if c.inlinedPages == nil {
+		c.inlinedPages = map[string]bool{
+			c.Page.ID: true,
+		}
+	}
+	id := notionapi.ToDashID(block.ID)
+	if c.inlinedPages[id] {
+		return false
+	}
+	subPage := c.PageByID(id)
+	if subPage == nil || subPage.Root() == nil {
+		log("sub-page %s not in Pages, can't render it inline\n", id)
+		return false
+	}
+	c.inlinedPages[id] = true
+
+	root := subPage.Root()
+	page := c.Page
+	c.Page = subPage
+	c.Printf(`<section id="%s" class="%s">`, block.ID, c.cls("notion-sub-page-content"))
+	{
+		c.Printf(`<h1 class="%s">`, c.cls("page-title"))
+		c.RenderInlines(root.InlineContent)
+		c.Printf(`</h1>`)
+		c.RenderChildren(root)
+	}
+	c.Printf(`</section>`)
+	c.Page = page
+	return true
+}
+
 func (c *Converter) renderSubPage(block *notionapi.Block) {
-	// TODO: probably a different look
-	c.renderLinkToPage(block)
+	if c.InlineSubPages && c.renderSubPageInline(block) {
+		return
+	}
+	if c.NotionCompat {
+		// Notion's export renders sub-pages like links to pages
+		c.renderLinkToPage(block)
+		return
+	}
+	c.renderPageLink(block, "notion-sub-page")
 }
 
 // RenderPage renders BlockPage
@@ -617,8 +1381,10 @@ func (c *Converter) RenderPage(block *notionapi.Block) {
 
 	if c.Page.IsSubPage(block) {
 		c.renderSubPage(block)
-	} else {
+	} else if c.NotionCompat {
 		c.renderLinkToPage(block)
+	} else {
+		c.renderMentionPage(block)
 	}
 }
 
@@ -633,14 +1399,19 @@ func getBlockColorClass(block *notionapi.Block) string {
 // RenderText renders BlockText
 func (c *Converter) RenderText(block *notionapi.Block) {
 	cls := getBlockColorClass(block)
-	c.Printf(`<p id="%s" class="%s">`, block.ID, cls)
+	c.Printf(`<p id="%s"%s>`, block.ID, c.classAttr(cls))
 	c.RenderInlines(block.InlineContent)
 	c.RenderChildren(block)
 	c.Printf(`</p>`)
 }
 
-func equationToHTML(katexPath string, equation string) (string, error) {
-	cmd := exec.Command(katexPath, "-d")
+// if displayMode is false, renders equation for use inline with text
+func equationToHTML(katexPath string, equation string, displayMode bool) (string, error) {
+	var args []string
+	if displayMode {
+		args = append(args, "-d")
+	}
+	cmd := exec.Command(katexPath, args...)
 	stdin, err := cmd.StdinPipe()
 	if err != nil {
 		return "", err
@@ -667,31 +1438,49 @@ func equationToHTML(katexPath string, equation string) (string, error) {
 	return res, nil
 }
 
+// katexCSSImport returns html that imports katex CSS the first time it's
+// called and empty string after that
+func (c *Converter) katexCSSImport() string {
+	if c.didImportKatexCSS {
+		return ""
+	}
+	c.didImportKatexCSS = true
+	return `<style>@import url('https://cdnjs.cloudflare.com/ajax/libs/KaTeX/0.10.0/katex.min.css')</style>`
+}
+
+// renderInlineEquation returns html for AttrEquation. Inline equations can
+// also be in cells of collections
+func (c *Converter) renderInlineEquation(equation string) string {
+	if c.UseKatexToRenderEquation {
+		html, err := equationToHTML(c.KatexPath, equation, false)
+		if err == nil {
+			return c.katexCSSImport() + fmt.Sprintf(`<span class="%s">`, c.cls("notion-text-equation-token")) + html + `</span>`
+		}
+	}
+	return fmt.Sprintf(`<span class="%s">%s</span>`, c.cls("notion-text-equation-token"), EscapeHTML(equation))
+}
+
 // RenderEquation renders BlockEquation
 func (c *Converter) RenderEquation(block *notionapi.Block) {
 	if !c.UseKatexToRenderEquation {
-		c.Printf(`<figure id="%s" class="equation">`, block.ID)
+		c.Printf(`<figure id="%s" class="%s">`, block.ID, c.cls("equation"))
 		c.RenderInlines(block.InlineContent)
 		c.Printf(`</figure>`)
 		return
 	}
-	ts := block.InlineContent
-	s := notionapi.TextSpansToString(ts)
-	html, err := equationToHTML(c.KatexPath, s)
+	s := block.PlainText()
+	html, err := equationToHTML(c.KatexPath, s, true)
 	if err != nil {
-		c.Printf(`<figure id="%s" class="equation">`, block.ID)
+		c.Printf(`<figure id="%s" class="%s">`, block.ID, c.cls("equation"))
 		c.RenderInlines(block.InlineContent)
 		c.Printf(`</figure>`)
 		return
 	}
 
-	c.Printf(`<figure id="%s" class="equation">`, block.ID)
+	c.Printf(`<figure id="%s" class="%s">`, block.ID, c.cls("equation"))
 	{
-		if !c.didImportKatexCSS {
-			c.Printf(`<style>@import url('https://cdnjs.cloudflare.com/ajax/libs/KaTeX/0.10.0/katex.min.css')</style>`)
-			c.didImportKatexCSS = true
-		}
-		c.Printf(`<div class="equation-container">`)
+		c.Printf(c.katexCSSImport())
+		c.Printf(`<div class="%s">`, c.cls("equation-container"))
 		{
 			c.Printf(html)
 		}
@@ -712,12 +1501,17 @@ func (c *Converter) RenderNumberedList(block *notionapi.Block) {
 
 	cls := getBlockColorClass(block) + " numbered-list"
 	cls = cleanAttr(cls)
-	c.Printf(`<ol id="%s" class="%s" start="%d">`, block.ID, cls, c.ListNo)
+	c.Printf(`<ol id="%s" class="%s" start="%d">`, block.ID, c.cls(cls), c.ListNo)
 	{
 		c.Printf(`<li>`)
 		{
-			c.RenderInlines(block.InlineContent)
-			c.RenderChildren(block)
+			f := block.FormatNumberedList()
+			if f != nil && f.Toggleable {
+				c.renderDetails(block, f.ToggleOpen)
+			} else {
+				c.RenderInlines(block.InlineContent)
+				c.RenderChildren(block)
+			}
 		}
 		c.Printf(`</li>`)
 	}
@@ -728,25 +1522,118 @@ func (c *Converter) RenderNumberedList(block *notionapi.Block) {
 func (c *Converter) RenderBulletedList(block *notionapi.Block) {
 	cls := getBlockColorClass(block) + " bulleted-list"
 	cls = cleanAttr(cls)
-	c.Printf(`<ul id="%s" class="%s">`, block.ID, cls)
+	c.Printf(`<ul id="%s" class="%s">`, block.ID, c.cls(cls))
 	{
 		c.Printf(`<li>`)
 		{
-			c.RenderInlines(block.InlineContent)
-			c.RenderChildren(block)
+			f := block.FormatBulletedList()
+			if f != nil && f.Toggleable {
+				c.renderDetails(block, f.ToggleOpen)
+			} else {
+				c.RenderInlines(block.InlineContent)
+				c.RenderChildren(block)
+			}
 		}
 		c.Printf(`</li>`)
 	}
 	c.Printf(`</ul>`)
 }
 
-// RenderHeaderLevel renders BlockHeader, SubHeader and SubSubHeader
-func (c *Converter) RenderHeaderLevel(block *notionapi.Block, level int) {
-	cls := getBlockColorClass(block)
-	c.Printf(`<h%d id="%s" class="%s">`, level, block.ID, cls)
-	id := block.ID
-	if c.AddHeaderAnchor {
-		c.Printf(`<a class="notion-header-anchor" href="#%s" aria-hidden="true"><svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 8 8"><path d="M5.88.03c-.18.01-.36.03-.53.09-.27.1-.53.25-.75.47a.5.5 0 1 0 .69.69c.11-.11.24-.17.38-.22.35-.12.78-.07 1.06.22.39.39.39 1.04 0 1.44l-1.5 1.5c-.44.44-.8.48-1.06.47-.26-.01-.41-.13-.41-.13a.5.5 0 1 0-.5.88s.34.22.84.25c.5.03 1.2-.16 1.81-.78l1.5-1.5c.78-.78.78-2.04 0-2.81-.28-.28-.61-.45-.97-.53-.18-.04-.38-.04-.56-.03zm-2 2.31c-.5-.02-1.19.15-1.78.75l-1.5 1.5c-.78.78-.78 2.04 0 2.81.56.56 1.36.72 2.06.47.27-.1.53-.25.75-.47a.5.5 0 1 0-.69-.69c-.11.11-.24.17-.38.22-.35.12-.78.07-1.06-.22-.39-.39-.39-1.04 0-1.44l1.5-1.5c.4-.4.75-.45 1.03-.44.28.01.47.09.47.09a.5.5 0 1 0 .44-.88s-.34-.2-.84-.22z"></path></svg></a>`, id)
+// slugify converts text to a readable id, e.g. "My Section!" => "my-section"
+func slugify(s string) string {
+	var res []rune
+	for _, r := range strings.ToLower(s) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			res = append(res, r)
+			continue
+		}
+		if len(res) > 0 && res[len(res)-1] != '-' {
+			res = append(res, '-')
+		}
+	}
+	return strings.TrimRight(string(res), "-")
+}
+
+// AnchorID returns id used for html element of a block. It's the block's
+// id unless SlugIDs is true, in which case headers get unique (within
+// the page) ids derived from their text
+func (c *Converter) AnchorID(block *notionapi.Block) string {
+	if !c.SlugIDs || !isHeaderBlock(block) {
+		return block.ID
+	}
+	if c.blockIDToSlug == nil {
+		c.blockIDToSlug = map[string]string{}
+		c.usedSlugs = map[string]bool{}
+		// assign in page order so that a header referenced before it's
+		// rendered (e.g. from table of contents) gets the same slug
+		c.assignSlugs(c.Page.Root(), map[string]bool{})
+	}
+	if slug, ok := c.blockIDToSlug[block.ID]; ok {
+		return slug
+	}
+	// e.g. a header of inlined sub-page
+	return c.assignSlug(block)
+}
+
+func (c *Converter) assignSlugs(block *notionapi.Block, visited map[string]bool) {
+	if block == nil || visited[block.ID] {
+		return
+	}
+	visited[block.ID] = true
+	if isHeaderBlock(block) {
+		c.assignSlug(block)
+	}
+	for _, child := range block.Content {
+		c.assignSlugs(child, visited)
+	}
+}
+
+func (c *Converter) assignSlug(block *notionapi.Block) string {
+	base := slugify(block.PlainText())
+	if base == "" {
+		base = "section"
+	}
+	slug := base
+	for n := 1; c.usedSlugs[slug]; n++ {
+		slug = fmt.Sprintf("%s-%d", base, n)
+	}
+	c.usedSlugs[slug] = true
+	c.blockIDToSlug[block.ID] = slug
+	return slug
+}
+
+// blockLinkAnchor returns "#${anchor id}" if uri links to a block in this
+// page (e.g. https://www.notion.so/Page-${page id}#${block id}) whose
+// anchor id is a slug (see SlugIDs). Otherwise returns empty string
+func (c *Converter) blockLinkAnchor(uri string) string {
+	if !c.SlugIDs || c.Page == nil {
+		return ""
+	}
+	u, err := url.Parse(uri)
+	if err != nil || u.Fragment == "" {
+		return ""
+	}
+	if u.Host != "" && !strings.HasSuffix(u.Host, "notion.so") {
+		return ""
+	}
+	pageID := notionapi.ExtractNoDashIDFromNotionURL(u.Path)
+	if u.Path != "" && pageID != notionapi.ToNoDashID(c.Page.ID) {
+		return ""
+	}
+	block := c.Page.BlockByID(notionapi.ToDashID(u.Fragment))
+	if block == nil || !isHeaderBlock(block) {
+		return ""
+	}
+	return "#" + c.AnchorID(block)
+}
+
+// RenderHeaderLevel renders BlockHeader, SubHeader and SubSubHeader
+func (c *Converter) RenderHeaderLevel(block *notionapi.Block, level int) {
+	cls := getBlockColorClass(block)
+	id := c.AnchorID(block)
+	c.Printf(`<h%d id="%s"%s>`, level, id, c.classAttr(cls))
+	if c.AddHeaderAnchor {
+		c.Printf(`<a class="%s" href="#%s" aria-hidden="true"><svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 8 8"><path d="M5.88.03c-.18.01-.36.03-.53.09-.27.1-.53.25-.75.47a.5.5 0 1 0 .69.69c.11-.11.24-.17.38-.22.35-.12.78-.07 1.06.22.39.39.39 1.04 0 1.44l-1.5 1.5c-.44.44-.8.48-1.06.47-.26-.01-.41-.13-.41-.13a.5.5 0 1 0-.5.88s.34.22.84.25c.5.03 1.2-.16 1.81-.78l1.5-1.5c.78-.78.78-2.04 0-2.81-.28-.28-.61-.45-.97-.53-.18-.04-.38-.04-.56-.03zm-2 2.31c-.5-.02-1.19.15-1.78.75l-1.5 1.5c-.78.78-.78 2.04 0 2.81.56.56 1.36.72 2.06.47.27-.1.53-.25.75-.47a.5.5 0 1 0-.69-.69c-.11.11-.24.17-.38.22-.35.12-.78.07-1.06-.22-.39-.39-.39-1.04 0-1.44l1.5-1.5c.4-.4.75-.45 1.03-.44.28.01.47.09.47.09a.5.5 0 1 0 .44-.88s-.34-.2-.84-.22z"></path></svg></a>`, c.cls("notion-header-anchor"), id)
 	}
 	c.RenderInlines(block.InlineContent)
 	c.Printf(`</h%d>`, level)
@@ -769,7 +1656,7 @@ func (c *Converter) RenderSubSubHeader(block *notionapi.Block) {
 
 // RenderTodo renders BlockTodo
 func (c *Converter) RenderTodo(block *notionapi.Block) {
-	c.Printf(`<ul id="%s" class="to-do-list">`, block.ID)
+	c.Printf(`<ul id="%s" class="%s">`, block.ID, c.cls("to-do-list"))
 	{
 		c.Printf(`<li>`)
 		{
@@ -777,13 +1664,21 @@ func (c *Converter) RenderTodo(block *notionapi.Block) {
 			if block.IsChecked {
 				cls = "checkbox-on"
 			}
-			c.Printf(`<div class="checkbox %s"></div>`, cls)
+			if c.InteractiveTodos {
+				checked := ""
+				if block.IsChecked {
+					checked = " checked"
+				}
+				c.Printf(`<input type="checkbox" class="%s" data-block-id="%s"%s/>`, c.cls("to-do-checkbox"), block.ID, checked)
+			} else {
+				c.Printf(`<div class="%s"></div>`, c.cls("checkbox "+cls))
+			}
 
 			cls = "to-do-children-unchecked"
 			if block.IsChecked {
 				cls = "to-do-children-checked"
 			}
-			c.Printf(`<span class="%s">`, cls)
+			c.Printf(`<span class="%s">`, c.cls(cls))
 			c.RenderInlines(block.InlineContent)
 			c.Printf(`</span>`)
 
@@ -794,23 +1689,34 @@ func (c *Converter) RenderTodo(block *notionapi.Block) {
 	c.Printf(`</ul>`)
 }
 
+// renderDetails renders content of a block as collapsible <details>
+// with block's text as <summary>. Used for toggles and toggleable
+// list items
+func (c *Converter) renderDetails(block *notionapi.Block, isOpen bool) {
+	// Notion's HTML export always expands toggles
+	if isOpen || c.ExpandAllToggles || c.NotionCompat {
+		c.Printf(`<details open="">`)
+	} else {
+		c.Printf(`<details>`)
+	}
+	{
+		c.Printf(`<summary>`)
+		c.RenderInlines(block.InlineContent)
+		c.Printf(`</summary>`)
+		c.RenderChildren(block)
+	}
+	c.Printf(`</details>`)
+}
+
 // RenderToggle renders BlockToggle
 func (c *Converter) RenderToggle(block *notionapi.Block) {
 	cls := getBlockColorClass(block) + " toggle"
 	cls = cleanAttr(cls)
-	c.Printf(`<ul id="%s" class="%s">`, block.ID, cls)
+	c.Printf(`<ul id="%s" class="%s">`, block.ID, c.cls(cls))
 	{
 		c.Printf(`<li>`)
-		{
-			c.Printf(`<details open="">`)
-			{
-				c.Printf(`<summary>`)
-				c.RenderInlines(block.InlineContent)
-				c.Printf(`</summary>`)
-				c.RenderChildren(block)
-			}
-			c.Printf(`</details>`)
-		}
+		format := block.FormatToggle()
+		c.renderDetails(block, format != nil && format.ToggleOpen)
 		c.Printf(`</li>`)
 	}
 	c.Printf(`</ul>`)
@@ -818,7 +1724,11 @@ func (c *Converter) RenderToggle(block *notionapi.Block) {
 
 // RenderQuote renders BlockQuote
 func (c *Converter) RenderQuote(block *notionapi.Block) {
-	c.Printf(`<blockquote id="%s" class="">`, block.ID)
+	cls := getBlockColorClass(block)
+	if size, _ := block.PropAsString("format.quote_size"); size == "large" && !c.NotionCompat {
+		cls += " quote-large"
+	}
+	c.Printf(`<blockquote id="%s"%s>`, block.ID, c.classAttr(cls))
 	{
 		c.RenderInlines(block.InlineContent)
 		// TODO: do they have children?
@@ -839,22 +1749,123 @@ func cleanAttr(v string) string {
 	}
 }
 
+// calloutAdmonitions maps emoji icon of a callout (without variation
+// selector) to admonition type
+var calloutAdmonitions = map[string]string{
+	"💡": "tip",
+	"⚠": "warning",
+	"ℹ": "note",
+	"📝": "note",
+	"❗": "important",
+	"🚨": "danger",
+	"⛔": "danger",
+}
+
+// renderAdmonition renders a callout as
+// <div class="admonition admonition-${type}"> with a title if its icon
+// maps to an admonition type. Returns false if it doesn't
+func (c *Converter) renderAdmonition(block *notionapi.Block) bool {
+	pageIcon, _ := block.PropAsString("format.page_icon")
+	kind := calloutAdmonitions[strings.Replace(pageIcon, "\ufe0f", "", -1)]
+	if kind == "" {
+		return false
+	}
+	title := strings.ToUpper(kind[:1]) + kind[1:]
+	c.Printf(`<div id="%s" class="%s">`, block.ID, c.cls("admonition admonition-"+kind))
+	{
+		c.Printf(`<p class="%s">%s</p>`, c.cls("admonition-title"), title)
+		c.Printf(`<div class="%s">`, c.cls("admonition-content"))
+		c.RenderInlines(block.InlineContent)
+		c.RenderChildren(block)
+		c.Printf(`</div>`)
+	}
+	c.Printf(`</div>`)
+	return true
+}
+
+// RenderSyncedBlock renders BlockTransclusionContainer
+func (c *Converter) RenderSyncedBlock(block *notionapi.Block) {
+	c.Printf(`<div id="%s" class="%s">`, block.ID, c.cls("notion-synced-block"))
+	c.renderContent(block)
+	c.Printf(`</div>`)
+}
+
+// RenderSyncedBlockReference renders BlockTransclusionReference with
+// content of the block it's synced from
+func (c *Converter) RenderSyncedBlockReference(block *notionapi.Block) {
+	source := c.Page.BlockByID(block.SyncedFromID())
+	if source == nil {
+		log("missing source %s of synced block %s\n", block.SyncedFromID(), block.ID)
+		return
+	}
+	c.Printf(`<div id="%s" class="%s">`, block.ID, c.cls("notion-synced-block"))
+	if c.ShowSyncedFrom {
+		c.renderSyncedFrom(source)
+	}
+	c.renderContent(source)
+	c.Printf(`</div>`)
+}
+
+// renderSyncedFrom renders a label with a link to the page with the
+// original of a synced block
+func (c *Converter) renderSyncedFrom(source *notionapi.Block) {
+	page := c.Page.BlockByID(source.ParentID)
+	for page != nil && page.Type != notionapi.BlockPage {
+		page = c.Page.BlockByID(page.ParentID)
+	}
+	c.Printf(`<div class="%s">Synced`, c.cls("notion-synced-from"))
+	if page != nil {
+		title := page.Title
+		if title == "" {
+			title = "Untitled"
+		}
+		c.Printf(` from <a href="%s">%s</a>`, filePathForPage(page), EscapeHTML(title))
+	}
+	c.Printf(`</div>`)
+}
+
 // RenderCallout renders BlockCallout
 func (c *Converter) RenderCallout(block *notionapi.Block) {
-	cls := getBlockColorClass(block) + " callout"
-	cls = cleanAttr(cls)
-	c.Printf(`<figure class="%s" style="white-space:pre-wrap;display:flex" id="%s">`, cls, block.ID)
+	if c.CalloutAdmonitions && c.renderAdmonition(block) {
+		return
+	}
+	colorCls := getBlockColorClass(block)
+	cls := cleanAttr(colorCls + " callout")
+	style := "white-space:pre-wrap;display:flex"
+	// background colors come from block-color-*_background class.
+	// Callouts without background color have a border
+	if !c.NotionCompat && !strings.HasSuffix(colorCls, "_background") {
+		style += ";border:1px solid rgba(55,53,47,0.16)"
+	}
+	c.Printf(`<figure class="%s" style="%s" id="%s">`, c.cls(cls), style, block.ID)
 	{
-		c.Printf(`<div style="font-size:1.5em">`)
+		iconColor := ""
+		if !c.NotionCompat {
+			iconColor, _ = block.PropAsString("format.page_icon_background")
+		}
+		if iconColor == "" {
+			c.Printf(`<div style="font-size:1.5em">`)
+		} else {
+			// e.g. "red" or "red_background", both use background color
+			iconColor = strings.TrimSuffix(iconColor, "_background")
+			iconCls := "block-color-" + iconColor + "_background callout-icon"
+			c.Printf(`<div class="%s" style="font-size:1.5em">`, c.cls(iconCls))
+		}
 		{
 			pageIcon, _ := block.PropAsString("format.page_icon")
-			c.Printf(`<span class="icon">%s</span>`, pageIcon)
+			if isURL(pageIcon) {
+				fileName := c.getDownloadedFileName(pageIcon, block)
+				c.Printf(`<img class="%s" src="%s"/>`, c.cls("icon"), fileName)
+			} else {
+				c.Printf(`<span class="%s">%s</span>`, c.cls("icon"), c.emojiHTML(pageIcon))
+			}
 		}
 		c.Printf(`</div>`)
 
 		{
 			c.Printf("%s", `<div style="width:100%">`)
 			c.RenderInlines(block.InlineContent)
+			c.RenderChildren(block)
 			c.Printf(`</div>`)
 		}
 	}
@@ -869,6 +1880,8 @@ func isHeaderBlock(block *notionapi.Block) bool {
 	return false
 }
 
+// getHeaderBlocks returns headers in blocks, recursively. Content of
+// sub-pages is skipped because it's not part of this page
 func getHeaderBlocks(blocks []*notionapi.Block) []*notionapi.Block {
 	var res []*notionapi.Block
 	for _, b := range blocks {
@@ -876,7 +1889,7 @@ func getHeaderBlocks(blocks []*notionapi.Block) []*notionapi.Block {
 			res = append(res, b)
 			continue
 		}
-		if len(b.Content) == 0 {
+		if len(b.Content) == 0 || b.Type == notionapi.BlockPage {
 			continue
 		}
 		sub := getHeaderBlocks(b.Content)
@@ -918,24 +1931,52 @@ func adjustIndent(blocks []*notionapi.Block, i int) int {
 func (c *Converter) RenderTableOfContents(block *notionapi.Block) {
 	cls := getBlockColorClass(block) + " table_of_contents"
 	cls = cleanAttr(cls)
-	c.Printf(`<nav id="%s" class="%s">`, block.ID, cls)
+	c.Printf(`<nav id="%s" class="%s">`, block.ID, c.cls(cls))
 	blocks := getHeaderBlocks(c.Page.Root().Content)
 	indent := 0
 	for i, b := range blocks {
 		indent += adjustIndent(blocks, i)
 		s := c.GetInlineContent(b.InlineContent)
-		c.Printf(`<div class="table_of_contents-item table_of_contents-indent-%d">`, indent)
+		itemCls := fmt.Sprintf("table_of_contents-item table_of_contents-indent-%d", indent)
+		c.Printf(`<div class="%s">`, c.cls(itemCls))
 		{
-			c.Printf(`<a class="table_of_contents-link" href="#%s">%s</a>`, b.ID, s)
+			c.Printf(`<a class="%s" href="#%s">%s</a>`, c.cls("table_of_contents-link"), c.AnchorID(b), s)
 		}
 		c.Printf(`</div>`)
 	}
 	c.Printf(`</nav>`)
 }
 
+var validDividerTags = []string{"hr", "div", "span", "section"}
+
 // RenderDivider renders BlockDivider
 func (c *Converter) RenderDivider(block *notionapi.Block) {
-	c.Printf(`<hr id="%s"/>`, block.ID)
+	if c.NotionCompat {
+		c.Printf(`<hr id="%s"/>`, block.ID)
+		return
+	}
+	cls := c.classAttr(getBlockColorClass(block) + " notion-divider")
+	tag := c.DividerTag
+	if tag == "" || tag == "hr" || !hasString(validDividerTags, tag) {
+		c.Printf(`<hr id="%s"%s/>`, block.ID, cls)
+		return
+	}
+	c.Printf(`<%s id="%s"%s></%s>`, tag, block.ID, cls, tag)
+}
+
+// captionID returns id of <figcaption> of a block. Block ids are unique
+// so caption ids are too
+func captionID(block *notionapi.Block) string {
+	return "caption-" + block.ID
+}
+
+// captionRefAttr returns aria-describedby attribute that links <figure>
+// with its caption if AccessibleCaptions is set and block has a caption
+func (c *Converter) captionRefAttr(block *notionapi.Block) string {
+	if !c.AccessibleCaptions || block.GetCaption() == nil {
+		return ""
+	}
+	return fmt.Sprintf(` aria-describedby="%s"`, captionID(block))
 }
 
 func (c *Converter) RenderCaption(block *notionapi.Block) {
@@ -943,18 +1984,28 @@ func (c *Converter) RenderCaption(block *notionapi.Block) {
 	if caption == nil {
 		return
 	}
-	c.Printf(`<figcaption>`)
+	if c.AccessibleCaptions {
+		c.Printf(`<figcaption id="%s">`, captionID(block))
+	} else {
+		c.Printf(`<figcaption>`)
+	}
 	c.RenderInlines(caption)
 	c.Printf(`</figcaption>`)
 }
 
 // RenderBookmark renders BlockBookmark
 func (c *Converter) RenderBookmark(block *notionapi.Block) {
-	c.Printf(`<figure id="%s">`, block.ID)
+	format := block.FormatBookmark()
+	hasMeta := block.Description != "" || (format != nil && (format.Icon != "" || format.Cover != ""))
+	if hasMeta && !c.NotionCompat {
+		c.renderBookmarkCard(block, format)
+		return
+	}
+	c.Printf(`<figure id="%s"%s>`, block.ID, c.captionRefAttr(block))
 	{
 		cls := getBlockColorClass(block) + " bookmark source"
 		cls = cleanAttr(cls)
-		c.Printf(`<div class="%s">`, cls)
+		c.Printf(`<div class="%s">`, c.cls(cls))
 		{
 			uri := block.Link
 			text := block.Title
@@ -968,17 +2019,54 @@ func (c *Converter) RenderBookmark(block *notionapi.Block) {
 	c.Printf(`</figure>`)
 }
 
+// renderBookmarkCard renders a bookmark with favicon, description and
+// cover image, like Notion does
+func (c *Converter) renderBookmarkCard(block *notionapi.Block, format *notionapi.FormatBookmark) {
+	var icon, cover string
+	if format != nil {
+		icon = format.Icon
+		cover = format.Cover
+	}
+	uri := EscapeHTML(block.Link)
+	c.Printf(`<figure id="%s"%s>`, block.ID, c.captionRefAttr(block))
+	{
+		cls := getBlockColorClass(block) + " bookmark source"
+		cls = cleanAttr(cls)
+		c.Printf(`<a href="%s" class="%s">`, uri, c.cls(cls))
+		{
+			c.Printf(`<div class="%s">`, c.cls("bookmark-info"))
+			{
+				c.Printf(`<div class="%s">`, c.cls("bookmark-text"))
+				c.Printf(`<div class="%s">%s</div>`, c.cls("bookmark-title"), EscapeHTML(block.Title))
+				if block.Description != "" {
+					c.Printf(`<div class="%s">%s</div>`, c.cls("bookmark-description"), EscapeHTML(block.Description))
+				}
+				c.Printf(`</div>`)
+				c.Printf(`<div class="%s">`, c.cls("bookmark-href"))
+				if icon != "" {
+					c.Printf(`<img src="%s" class="%s"/>`, EscapeHTML(icon), c.cls("icon bookmark-icon"))
+				}
+				c.Printf(`%s</div>`, uri)
+			}
+			c.Printf(`</div>`)
+			if cover != "" {
+				c.Printf(`<img src="%s" class="%s"/>`, EscapeHTML(cover), c.cls("bookmark-image"))
+			}
+		}
+		c.Printf(`</a>`)
+		c.RenderCaption(block)
+	}
+	c.Printf(`</figure>`)
+}
+
 // RenderAudio renders BlockAudio
 func (c *Converter) RenderAudio(block *notionapi.Block) {
-	c.Printf(`<figure id="%s">`, block.ID)
+	c.Printf(`<figure id="%s"%s>`, block.ID, c.captionRefAttr(block))
 	{
-		c.Printf(`<div class="source">`)
+		c.Printf(`<div class="%s">`, c.cls("source"))
 		{
 			source := block.Source
-			fileName := source
-			if len(block.FileIDs) > 0 {
-				fileName = getDownloadedFileName(source, block)
-			}
+			fileName := c.getFileOrSourceURL(block)
 			if source == "" {
 				c.Printf(`<a></a>`)
 			} else {
@@ -991,17 +2079,88 @@ func (c *Converter) RenderAudio(block *notionapi.Block) {
 	c.Printf(`</figure>`)
 }
 
+// videoDimensions returns width (in pixels) and aspect ratio (height / width,
+// e.g. 0.5625 for 16:9 video) of a video block. Values are 0 if unknown.
+// They're needed to size a video player so that the layout doesn't jump
+// when the video loads
+func videoDimensions(block *notionapi.Block) (int, float64) {
+	format := block.FormatVideo()
+	if format == nil {
+		return 0, 0
+	}
+	width := int(format.BlockWidth)
+	aspectRatio := format.BlockAspectRatio
+	if aspectRatio == 0 && format.BlockWidth > 0 && format.BlockHeight > 0 {
+		aspectRatio = float64(format.BlockHeight) / float64(format.BlockWidth)
+	}
+	return width, aspectRatio
+}
+
+// videoPoster returns url of an image to show before a video is played
+// (poster attribute of <video>) or empty string if the block doesn't have one
+func videoPoster(block *notionapi.Block) string {
+	format := block.FormatVideo()
+	if format == nil {
+		return ""
+	}
+	if format.BlockThumbnail != "" {
+		return format.BlockThumbnail
+	}
+	return format.BlockCover
+}
+
+var videoFileExts = map[string]bool{
+	".mp4":  true,
+	".m4v":  true,
+	".webm": true,
+	".ogv":  true,
+	".ogg":  true,
+	".mov":  true,
+}
+
+// videoStyle returns style for a video player that sizes it before
+// the video loads or empty string if dimensions are unknown
+func videoStyle(block *notionapi.Block) string {
+	width, aspectRatio := videoDimensions(block)
+	style := ""
+	if width > 0 {
+		style += fmt.Sprintf("width:%dpx;max-width:100%%;", width)
+	}
+	if aspectRatio > 0 {
+		style += fmt.Sprintf("aspect-ratio:1/%v;", aspectRatio)
+	}
+	return style
+}
+
+// renderVideoPlayer renders <video> for videos that are files
+// (as opposed to e.g. YouTube links)
+func (c *Converter) renderVideoPlayer(block *notionapi.Block) {
+	ext := strings.ToLower(path.Ext(fileNameFromURL(block.Source)))
+	if !videoFileExts[ext] {
+		return
+	}
+	uri := EscapeHTML(c.getFileOrSourceURL(block))
+	attrs := ""
+	if poster := videoPoster(block); poster != "" {
+		attrs = fmt.Sprintf(` poster="%s"`, EscapeHTML(poster))
+	}
+	if s := videoStyle(block); s != "" {
+		attrs += fmt.Sprintf(` style="%s"`, s)
+	}
+	c.Printf(`<video class="%s" controls preload="metadata" src="%s"%s></video>`, c.cls("notion-video"), uri, attrs)
+}
+
 // RenderVideo renders BlockVideo
 func (c *Converter) RenderVideo(block *notionapi.Block) {
-	c.Printf(`<figure id="%s">`, block.ID)
+	c.Printf(`<figure id="%s"%s>`, block.ID, c.captionRefAttr(block))
 	{
-		c.Printf(`<div class="source">`)
+		if !c.NotionCompat {
+			c.renderVideoPlayer(block)
+		}
+		c.Printf(`<div class="%s">`, c.cls("source"))
 		{
 			source := block.Source
-			fileName := source
-			if len(block.FileIDs) > 0 {
-				fileName = getDownloadedFileName(source, block)
-			}
+			fileName := c.getFileOrSourceURL(block)
 			if source == "" {
 				c.Printf(`<a></a>`)
 			} else {
@@ -1015,11 +2174,11 @@ func (c *Converter) RenderVideo(block *notionapi.Block) {
 }
 
 func (c *Converter) renderEmbed(block *notionapi.Block) {
-	c.Printf(`<figure id="%s">`, block.ID)
+	c.Printf(`<figure id="%s"%s>`, block.ID, c.captionRefAttr(block))
 	{
-		c.Printf(`<div class="source">`)
+		c.Printf(`<div class="%s">`, c.cls("source"))
 		{
-			uri := block.Source
+			uri := normalizeNotionURL(block.Source)
 			c.A(uri, uri, "")
 		}
 		c.Printf(`</div>`)
@@ -1050,11 +2209,11 @@ func (c *Converter) RenderMaps(block *notionapi.Block) {
 
 // RenderEmbed renders BlockEmbed
 func (c *Converter) RenderEmbed(block *notionapi.Block) {
-	c.Printf(`<figure id="%s">`, block.ID)
+	c.Printf(`<figure id="%s"%s>`, block.ID, c.captionRefAttr(block))
 	{
-		c.Printf(`<div class="source">`)
+		c.Printf(`<div class="%s">`, c.cls("source"))
 		{
-			uri := getFileOrSourceURL(block)
+			uri := c.getFileOrSourceURL(block)
 			text := block.Source
 			c.A(uri, text, "")
 		}
@@ -1066,11 +2225,11 @@ func (c *Converter) RenderEmbed(block *notionapi.Block) {
 
 // RenderFigma renders BlockFigma
 func (c *Converter) RenderFigma(block *notionapi.Block) {
-	c.Printf(`<figure id="%s">`, block.ID)
+	c.Printf(`<figure id="%s"%s>`, block.ID, c.captionRefAttr(block))
 	{
-		c.Printf(`<div class="source">`)
+		c.Printf(`<div class="%s">`, c.cls("source"))
 		{
-			uri := block.Source
+			uri := normalizeNotionURL(block.Source)
 			c.Printf(`<a href="%s">%s</a>`, uri, uri)
 		}
 
@@ -1082,12 +2241,19 @@ func (c *Converter) RenderFigma(block *notionapi.Block) {
 
 // RenderFile renders BlockFile
 func (c *Converter) RenderFile(block *notionapi.Block) {
-	c.Printf(`<figure id="%s">`, block.ID)
+	c.Printf(`<figure id="%s"%s>`, block.ID, c.captionRefAttr(block))
 	{
-		c.Printf(`<div class="source">`)
+		if !c.NotionCompat {
+			c.renderFilePreview(block)
+		}
+		c.Printf(`<div class="%s">`, c.cls("source"))
 		{
-			uri := getDownloadedFileName(block.Source, block)
-			c.A(uri, block.Source, "")
+			uri := c.getDownloadedFileName(block.Source, block)
+			if c.NotionCompat {
+				c.A(uri, block.Source, "")
+			} else {
+				c.renderFileLink(uri, block)
+			}
 		}
 		c.Printf(`</div>`)
 		c.RenderCaption(block)
@@ -1095,11 +2261,65 @@ func (c *Converter) RenderFile(block *notionapi.Block) {
 	c.Printf(`</figure>`)
 }
 
+var imageFileExts = map[string]bool{
+	".png":  true,
+	".jpg":  true,
+	".jpeg": true,
+	".gif":  true,
+	".webp": true,
+	".svg":  true,
+	".bmp":  true,
+	".avif": true,
+}
+
+// renderFilePreview renders a thumbnail of a file if it's an image
+// or embeds it if it's a pdf and EmbedPDFs is set
+func (c *Converter) renderFilePreview(block *notionapi.Block) {
+	ext := strings.ToLower(path.Ext(fileNameFromURL(block.Source)))
+	if !imageFileExts[ext] && (ext != ".pdf" || !c.EmbedPDFs) {
+		return
+	}
+	uri := EscapeHTML(c.getDownloadedFileName(block.Source, block))
+	if ext == ".pdf" {
+		c.Printf(`<object class="%s" data="%s" type="application/pdf"></object>`, c.cls("notion-file-preview"), uri)
+		return
+	}
+	c.Printf(`<a href="%s"><img class="%s" src="%s"/></a>`, uri, c.cls("notion-file-preview"), uri)
+}
+
+// fileNameFromURL returns un-escaped file name part of the url
+func fileNameFromURL(uri string) string {
+	if idx := strings.IndexAny(uri, "?#"); idx != -1 {
+		uri = uri[:idx]
+	}
+	name := urlBaseName(uri)
+	if s, err := url.PathUnescape(name); err == nil {
+		name = s
+	}
+	return name
+}
+
+// renderFileLink renders a link to a file with an icon and file name
+// (and size, if known) instead of a raw url
+func (c *Converter) renderFileLink(uri string, block *notionapi.Block) {
+	name := fileNameFromURL(block.Source)
+	if name == "" {
+		name = block.Source
+	}
+	c.Printf(`<a class="%s" href="%s">`, c.cls("notion-file"), EscapeHTML(uri))
+	c.Printf(`<span class="%s">📄</span>`, c.cls("notion-file-icon"))
+	c.Printf(`<span class="%s">%s</span>`, c.cls("notion-file-name"), EscapeHTML(name))
+	if block.FileSize != "" {
+		c.Printf(`<span class="%s">%s</span>`, c.cls("notion-file-size"), EscapeHTML(block.FileSize))
+	}
+	c.Printf(`</a>`)
+}
+
 // RenderDrive renders BlockDrive
 func (c *Converter) RenderDrive(block *notionapi.Block) {
-	c.Printf(`<figure id="%s">`, block.ID)
+	c.Printf(`<figure id="%s"%s>`, block.ID, c.captionRefAttr(block))
 	{
-		c.Printf(`<div class="bookmark source">`)
+		c.Printf(`<div class="%s">`, c.cls("bookmark source"))
 		{
 			icon, _ := block.PropAsString("format.drive_properties.icon")
 			c.Printf(`<img style="width:1em;height:1em;margin-right:0.5em;vertical-align:text-bottom" src="%s"/>`, icon)
@@ -1108,7 +2328,7 @@ func (c *Converter) RenderDrive(block *notionapi.Block) {
 			title, _ := block.PropAsString("format.drive_properties.title")
 			c.Printf(`<a href="%s">%s</a>`, docURL, title)
 			c.Printf(`<br/>`)
-			c.Printf(`<a class="bookmark-href" href="%s">%s</a>`, docURL, docURL)
+			c.Printf(`<a class="%s" href="%s">%s</a>`, c.cls("bookmark-href"), docURL, docURL)
 		}
 		c.Printf(`</div>`)
 		c.RenderCaption(block)
@@ -1118,10 +2338,10 @@ func (c *Converter) RenderDrive(block *notionapi.Block) {
 
 // RenderPDF renders BlockPDF
 func (c *Converter) RenderPDF(block *notionapi.Block) {
-	c.Printf(`<figure id="%s">`, block.ID)
+	c.Printf(`<figure id="%s"%s>`, block.ID, c.captionRefAttr(block))
 	{
-		c.Printf(`<div class="source">`)
-		uri := getDownloadedFileName(block.Source, block)
+		c.Printf(`<div class="%s">`, c.cls("source"))
+		uri := c.getDownloadedFileName(block.Source, block)
 		c.A(uri, block.Source, "")
 		c.Printf(`</div>`)
 		c.RenderCaption(block)
@@ -1139,9 +2359,9 @@ func getImageStyle(block *notionapi.Block) string {
 
 // RenderImage renders BlockImage
 func (c *Converter) RenderImage(block *notionapi.Block) {
-	c.Printf(`<figure id="%s" class="image">`, block.ID)
+	c.Printf(`<figure id="%s" class="%s"%s>`, block.ID, c.cls("image"), c.captionRefAttr(block))
 	{
-		uri := getFileOrSourceURL(block)
+		uri := c.getFileOrSourceURL(block)
 		style := getImageStyle(block)
 		c.Printf(`<a href="%s">`, uri)
 		c.Printf(`<img %ssrc="%s"/>`, style, uri)
@@ -1160,24 +2380,93 @@ func (c *Converter) RenderColumnList(block *notionapi.Block) {
 		maybePanic("has no columns")
 		return
 	}
-	c.Printf(`<div id="%s" class="column-list">`, block.ID)
+	c.Printf(`<div id="%s" class="%s">`, block.ID, c.cls("column-list"))
 	c.RenderChildren(block)
 	c.Printf(`</div>`)
 }
 
+func columnRatio(block *notionapi.Block, nColumns int) float64 {
+	fc := block.FormatColumn()
+	if fc == nil || fc.ColumnRatio <= 0 {
+		return 1 / float64(nColumns)
+	}
+	return fc.ColumnRatio
+}
+
+// columnWidthPercent returns width of a column as percentage of its
+// column list. Notion doesn't guarantee that ratios of columns add up to
+// 1 (they often don't for a column list nested in a column) so we
+// normalize them with ratios of sibling columns
+func (c *Converter) columnWidthPercent(block *notionapi.Block) float64 {
+	parent := block.Parent
+	if parent == nil && c.Page != nil {
+		parent = c.Page.BlockByID(block.ParentID)
+	}
+	if parent == nil || parent.Type != notionapi.BlockColumnList || len(parent.Content) == 0 {
+		return columnRatio(block, 2) * 100
+	}
+	nColumns := len(parent.Content)
+	var total float64
+	for _, col := range parent.Content {
+		total += columnRatio(col, nColumns)
+	}
+	return columnRatio(block, nColumns) / total * 100
+}
+
 // RenderColumn renders BlockColumn
 // it's parent is BlockColumnList
 func (c *Converter) RenderColumn(block *notionapi.Block) {
 	var colRatio float64 = 50
-	fc := block.FormatColumn()
-	if fc != nil {
-		colRatio = fc.ColumnRatio * 100
+	if c.NotionCompat {
+		fc := block.FormatColumn()
+		if fc != nil {
+			colRatio = fc.ColumnRatio * 100
+		}
+	} else {
+		colRatio = c.columnWidthPercent(block)
 	}
-	c.Printf(`<div id="%s" style="width:%v%%" class="column">`, block.ID, colRatio)
+	c.Printf(`<div id="%s" style="width:%v%%" class="%s">`, block.ID, colRatio, c.cls("column"))
 	c.RenderChildren(block)
 	c.Printf("</div>")
 }
 
+// RenderTable renders BlockTable
+// it's children are BlockTableRow
+func (c *Converter) RenderTable(block *notionapi.Block) {
+	c.Printf(`<table id="%s" class="%s">`, block.ID, c.cls("simple-table"))
+	{
+		c.Printf(`<tbody>`)
+		c.RenderChildren(block)
+		c.Printf(`</tbody>`)
+	}
+	c.Printf(`</table>`)
+}
+
+// RenderTableRow renders BlockTableRow
+// it's parent is BlockTable
+func (c *Converter) RenderTableRow(block *notionapi.Block) {
+	var f *notionapi.FormatTable
+	if block.Parent != nil && block.Parent.Type == notionapi.BlockTable {
+		f = block.Parent.FormatTable()
+	}
+	if f == nil {
+		maybePanic("table_row %s has no parent table format", block.ID)
+		return
+	}
+	isHeaderRow := f.ColumnHeader && c.CurrBlockIdx == 0
+	c.Printf(`<tr id="%s">`, block.ID)
+	for i, colID := range f.ColumnOrder {
+		tag := "td"
+		if isHeaderRow || (f.RowHeader && i == 0) {
+			tag = "th"
+		}
+		c.Printf(`<%s>`, tag)
+		c.RenderInlines(block.GetProperty(colID))
+		c.Printf(`</%s>`, tag)
+	}
+	c.Printf(`</tr>`)
+}
+
 // RenderBreadcrumb renders BlockBreadcrumb
 func (c *Converter) RenderBreadcrumb(block *notionapi.Block) {
 	if c.NotionCompat {
@@ -1192,6 +2481,41 @@ func (c *Converter) RenderNYI(block *notionapi.Block) {
 	c.Printf("<div>TODO: '%s' NYI!</div>", block.Type)
 }
 
+// visibleTableProperties returns columns of a table view that are not
+// hidden. Title column can't be hidden in Notion
+func visibleTableProperties(columns []*notionapi.TableProperty) []*notionapi.TableProperty {
+	var res []*notionapi.TableProperty
+	for _, col := range columns {
+		if col.Visible || col.Property == "title" {
+			res = append(res, col)
+		}
+	}
+	return res
+}
+
+// renderTableColumnWidths renders <colgroup> with widths of columns set
+// in the view. Columns without width are sized automatically
+func (c *Converter) renderTableColumnWidths(columns []*notionapi.TableProperty) {
+	hasWidth := false
+	for _, col := range columns {
+		if col.Width > 0 {
+			hasWidth = true
+		}
+	}
+	if !hasWidth {
+		return
+	}
+	c.Printf(`<colgroup>`)
+	for _, col := range columns {
+		if col.Width > 0 {
+			c.Printf(`<col style="width:%dpx"/>`, col.Width)
+		} else {
+			c.Printf(`<col/>`)
+		}
+	}
+	c.Printf(`</colgroup>`)
+}
+
 // RenderCollectionView renders BlockCollectionView
 func (c *Converter) RenderCollectionView(block *notionapi.Block) {
 	pageID := ""
@@ -1206,18 +2530,26 @@ func (c *Converter) RenderCollectionView(block *notionapi.Block) {
 	viewInfo := block.CollectionViews[0]
 	view := viewInfo.CollectionView
 	collection := viewInfo.Collection
+	if view == nil || collection == nil {
+		log("missing collection or collection view for block %s %s in page %s\n", block.ID, block.Type, pageID)
+		c.Printf(`<div id="%s" class="%s"></div>`, block.ID, c.cls("collection-content missing"))
+		return
+	}
 	if view.Format == nil {
 		log("missing view.Format for block %s %s in page %s\n", block.ID, block.Type, pageID)
 		return
 	}
 
-	columns := view.Format.TableProperties
-	c.Printf(`<div id="%s" class="collection-content">`, block.ID)
+	columns := visibleTableProperties(view.Format.TableProperties)
+	c.Printf(`<div id="%s" class="%s">`, block.ID, c.cls("collection-content"))
 	{
 		name := collection.Name()
-		c.Printf(`<h4 class="collection-title">%s</h4>`, name)
-		c.Printf(`<table class="collection-content">`)
+		c.Printf(`<h4 class="%s">%s</h4>`, c.cls("collection-title"), name)
+		c.Printf(`<table class="%s">`, c.cls("collection-content"))
 		{
+			if !c.NotionCompat {
+				c.renderTableColumnWidths(columns)
+			}
 			c.Printf(`<thead>`)
 			{
 				c.Printf(`<tr>`)
@@ -1235,51 +2567,131 @@ func (c *Converter) RenderCollectionView(block *notionapi.Block) {
 			}
 			c.Printf(`</thead>`)
 
-			c.Printf(`<tbody>`)
-			{
-				for _, row := range viewInfo.CollectionRows {
-					c.Printf(`<tr id="%s">`, row.ID)
-					props := row.Properties
-					for _, col := range columns {
-						colName := col.Property
-						v := props[colName]
-						inlineContent, err := notionapi.ParseTextSpans(v)
-						maybePanicIfErr(err, "ParseTextSpans of '%v' failed with %s\n", v, err)
-						colVal := c.GetInlineContent(inlineContent)
-						colInfo := viewInfo.Collection.CollectionSchema[colName]
-						if colInfo.Type == "title" {
-							uri := getTitleColDownloadedURL(row, block, viewInfo.Collection)
-							if colVal == "" {
-								colVal = "Untitled"
-							}
-							colVal = fmt.Sprintf(`<a href="%s">%s</a>`, uri, colVal)
-						} else if colInfo.Type == "multi_select" {
-							vals := strings.Split(colVal, ",")
-							s := ""
-							for i := range vals {
-								// TODO: Notion prints in reverse order
-								idx := len(vals) - 1 - i
-								v := EscapeHTML(vals[idx])
-								if v == "" {
-									continue
-								}
-								s += fmt.Sprintf(`<span class="selected-value">%s</span>`, v)
-							}
-							colVal = s
-						}
-						colNameCls := EscapeHTML(colName)
-						c.Printf(`<td class="cell-%s">%s</td>`, colNameCls, colVal)
+			groupBy := view.GroupByProperty()
+			if groupBy != "" && !c.NotionCompat {
+				for _, group := range groupCollectionRows(viewInfo, groupBy) {
+					c.Printf(`<tbody class="%s">`, c.cls("notion-collection-group"))
+					c.Printf(`<tr class="%s"><th colspan="%d">%s <span class="%s">%d</span></th></tr>`, c.cls("notion-collection-group-header"), len(columns), EscapeHTML(group.name), c.cls("notion-collection-group-count"), len(group.rows))
+					for _, row := range group.rows {
+						c.renderCollectionRow(block, viewInfo, columns, row)
 					}
-					c.Printf("</tr>\n")
+					c.Printf(`</tbody>`)
+				}
+			} else {
+				c.Printf(`<tbody>`)
+				for _, row := range viewInfo.CollectionRows {
+					c.renderCollectionRow(block, viewInfo, columns, row)
 				}
+				c.Printf(`</tbody>`)
 			}
-			c.Printf(`</tbody>`)
 		}
 		c.Printf(`</table>`)
 	}
 	c.Printf(`</div>`)
 }
 
+// collectionGroup is a group of rows in a grouped collection view
+type collectionGroup struct {
+	name string
+	rows []*notionapi.Block
+}
+
+// groupCollectionRows partitions rows of a collection view by value of
+// a given property. Groups are ordered like in view's collection_groups
+// (hidden groups are skipped) with groups not listed there at the end,
+// in order of first appearance
+func groupCollectionRows(viewInfo *notionapi.CollectionViewInfo, propName string) []*collectionGroup {
+	var groups []*collectionGroup
+	byValue := map[string]*collectionGroup{}
+	hidden := map[string]bool{}
+	for _, g := range viewInfo.CollectionView.Format.CollectionGroups {
+		v := ""
+		if g.Value != nil && g.Value.Value != nil {
+			v = fmt.Sprintf("%v", g.Value.Value)
+		}
+		if g.Hidden {
+			hidden[v] = true
+			continue
+		}
+		if byValue[v] == nil {
+			byValue[v] = &collectionGroup{name: v}
+			groups = append(groups, byValue[v])
+		}
+	}
+	for _, row := range viewInfo.CollectionRows {
+		v := ""
+		if spans, err := notionapi.ParseTextSpans(row.Properties[propName]); err == nil {
+			v = notionapi.TextSpansToString(spans)
+		}
+		if hidden[v] {
+			continue
+		}
+		g := byValue[v]
+		if g == nil {
+			g = &collectionGroup{name: v}
+			byValue[v] = g
+			groups = append(groups, g)
+		}
+		g.rows = append(g.rows, row)
+	}
+
+	noValueName := "No value"
+	if colInfo := viewInfo.Collection.CollectionSchema[propName]; colInfo != nil && colInfo.Name != "" {
+		noValueName = "No " + colInfo.Name
+	}
+	var res []*collectionGroup
+	for _, g := range groups {
+		if len(g.rows) == 0 {
+			continue
+		}
+		if g.name == "" {
+			g.name = noValueName
+		}
+		res = append(res, g)
+	}
+	return res
+}
+
+// renderCollectionRow renders a row of a collection as <tr>
+func (c *Converter) renderCollectionRow(block *notionapi.Block, viewInfo *notionapi.CollectionViewInfo, columns []*notionapi.TableProperty, row *notionapi.Block) {
+	c.Printf(`<tr id="%s">`, row.ID)
+	props := row.Properties
+	for _, col := range columns {
+		colName := col.Property
+		v := props[colName]
+		inlineContent, err := notionapi.ParseTextSpans(v)
+		maybePanicIfErr(err, "ParseTextSpans of '%v' failed with %s\n", v, err)
+		colVal := c.GetInlineContent(inlineContent)
+		colInfo := viewInfo.Collection.CollectionSchema[colName]
+		if colInfo == nil {
+			colInfo = &notionapi.CollectionColumnInfo{}
+		}
+		if colInfo.Type == "title" {
+			uri := getTitleColDownloadedURL(row, block, viewInfo.Collection)
+			if colVal == "" {
+				colVal = "Untitled"
+			}
+			colVal = fmt.Sprintf(`<a href="%s">%s</a>`, uri, colVal)
+		} else if colInfo.Type == "multi_select" {
+			vals := strings.Split(colVal, ",")
+			s := ""
+			for i := range vals {
+				// TODO: Notion prints in reverse order
+				idx := len(vals) - 1 - i
+				v := EscapeHTML(vals[idx])
+				if v == "" {
+					continue
+				}
+				s += fmt.Sprintf(`<span class="%s">%s</span>`, c.cls("selected-value"), v)
+			}
+			colVal = s
+		}
+		colNameCls := EscapeHTML(colName)
+		c.Printf(`<td class="%s">%s</td>`, c.cls("cell-"+colNameCls), colVal)
+	}
+	c.Printf("</tr>\n")
+}
+
 // DefaultRenderFunc returns a defult rendering function for a type of
 // a given block
 func (c *Converter) DefaultRenderFunc(blockType string) func(*notionapi.Block) {
@@ -1318,6 +2730,12 @@ func (c *Converter) DefaultRenderFunc(blockType string) func(*notionapi.Block) {
 		return c.RenderColumnList
 	case notionapi.BlockColumn:
 		return c.RenderColumn
+	case notionapi.BlockTable:
+		return c.RenderTable
+	case notionapi.BlockAlias:
+		return c.RenderAlias
+	case notionapi.BlockTableRow:
+		return c.RenderTableRow
 	case notionapi.BlockCollectionView:
 		return c.RenderCollectionView
 	case notionapi.BlockCollectionViewPage:
@@ -1352,6 +2770,10 @@ func (c *Converter) DefaultRenderFunc(blockType string) func(*notionapi.Block) {
 		return c.RenderBreadcrumb
 	case notionapi.BlockFactory:
 		return nil
+	case notionapi.BlockTransclusionContainer:
+		return c.RenderSyncedBlock
+	case notionapi.BlockTransclusionReference:
+		return c.RenderSyncedBlockReference
 	default:
 		maybePanic("DefaultRenderFunc: unsupported block type '%s' in %s\n", blockType, c.Page.NotionURL())
 	}
@@ -1378,9 +2800,18 @@ func (c *Converter) RenderChildren(block *notionapi.Block) {
 	doIndent := needsIndent(block)
 	// provides indentation for children
 	if doIndent {
-		c.Printf(`<div class="indented">`)
+		c.Printf(`<div class="%s">`, c.cls("indented"))
+	}
+
+	c.renderContent(block)
+
+	if doIndent {
+		c.Printf(`</div>`)
 	}
+}
 
+// renderContent renders children of the block without indentation
+func (c *Converter) renderContent(block *notionapi.Block) {
 	currIdx := c.CurrBlockIdx
 	currBlocks := c.CurrBlocks
 	c.CurrBlocks = block.Content
@@ -1391,10 +2822,24 @@ func (c *Converter) RenderChildren(block *notionapi.Block) {
 	}
 	c.CurrBlockIdx = currIdx
 	c.CurrBlocks = currBlocks
+}
 
-	if doIndent {
-		c.Printf(`</div>`)
+func hasString(a []string, s string) bool {
+	for _, el := range a {
+		if el == s {
+			return true
+		}
+	}
+	return false
+}
+
+// isBlockTypeIncluded returns false if blocks of this type should not be
+// rendered because of IncludeBlockTypes or ExcludeBlockTypes
+func (c *Converter) isBlockTypeIncluded(blockType string) bool {
+	if len(c.IncludeBlockTypes) > 0 && !hasString(c.IncludeBlockTypes, blockType) {
+		return false
 	}
+	return !hasString(c.ExcludeBlockTypes, blockType)
 }
 
 // RenderBlock renders a block to html
@@ -1403,16 +2848,79 @@ func (c *Converter) RenderBlock(block *notionapi.Block) {
 		// a missing block is possible
 		return
 	}
+	isRoot := c.Page != nil && c.Page.IsRoot(block)
+	if !isRoot && !c.isBlockTypeIncluded(block.Type) {
+		// children of excluded blocks are skipped too. Content of
+		// a sub-page is not part of this page
+		if !hasString(c.ExcludeBlockTypes, block.Type) && !block.IsPage() {
+			c.renderContent(block)
+		}
+		return
+	}
+	// root page is handled in renderRootPage because it doesn't always
+	// render a wrapper element
+	if c.DataBlockID && !isRoot {
+		buf, start := c.Buf, c.Buf.Len()
+		defer addDataBlockID(buf, start, block.ID)
+	}
 	if c.RenderBlockOverride != nil {
 		handled := c.RenderBlockOverride(block)
 		if handled {
 			return
 		}
 	}
-	def := c.DefaultRenderFunc(block.Type)
-	if def != nil {
+	def := c.BlockRenderers[block.Type]
+	if def == nil {
+		def = c.DefaultRenderFunc(block.Type)
+	}
+	if def == nil {
+		return
+	}
+	if c.BlockTimer == nil {
 		def(block)
+		return
 	}
+	// includes time of rendering children
+	timeStart := time.Now()
+	def(block)
+	c.BlockTimer(block.Type, time.Since(timeStart))
+}
+
+// addDataBlockID adds data-block-id attribute to the first element
+// written to buf after start (<style> elements are skipped)
+func addDataBlockID(buf *bytes.Buffer, start int, blockID string) {
+	d := buf.Bytes()[start:]
+	pos := 0
+	for {
+		idx := bytes.IndexByte(d[pos:], '<')
+		if idx < 0 || pos+idx+1 >= len(d) {
+			return
+		}
+		pos += idx + 1
+		if bytes.HasPrefix(d[pos:], []byte("style")) {
+			end := bytes.Index(d[pos:], []byte("</style>"))
+			if end < 0 {
+				return
+			}
+			pos += end + len("</style>")
+			continue
+		}
+		if isASCIILetter(d[pos]) {
+			break
+		}
+	}
+	// skip the tag name
+	for pos < len(d) && (isASCIILetter(d[pos]) || (d[pos] >= '0' && d[pos] <= '9')) {
+		pos++
+	}
+	rest := append([]byte(nil), d[pos:]...)
+	buf.Truncate(start + pos)
+	fmt.Fprintf(buf, ` data-block-id="%s"`, blockID)
+	buf.Write(rest)
+}
+
+func isASCIILetter(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
 }
 
 func (c *Converter) detectKatex() error {
@@ -1435,6 +2943,12 @@ func (c *Converter) detectKatex() error {
 
 // ToHTML renders a page to html
 func (c *Converter) ToHTML() ([]byte, error) {
+	if c.RootTag != "" && !hasString(validRootTags, c.RootTag) {
+		return nil, fmt.Errorf("invalid RootTag '%s', must be one of: %s", c.RootTag, strings.Join(validRootTags, ", "))
+	}
+	if c.DividerTag != "" && !hasString(validDividerTags, c.DividerTag) {
+		return nil, fmt.Errorf("invalid DividerTag '%s', must be one of: %s", c.DividerTag, strings.Join(validDividerTags, ", "))
+	}
 	if c.NotionCompat {
 		c.UseKatexToRenderEquation = true
 	}
@@ -1444,12 +2958,114 @@ func (c *Converter) ToHTML() ([]byte, error) {
 		}
 	}
 
+	c.UnresolvedRefs = nil
+	c.LocalizedAssets = nil
+	c.inlinedPages = nil
 	c.PushNewBuffer()
 	c.RenderBlock(c.Page.Root())
 	buf := c.PopBuffer()
+	if c.Minify {
+		return minifyHTML(buf.Bytes()), nil
+	}
 	return buf.Bytes(), nil
 }
 
+// RenderBlockToHTML renders a single block (and its children) to HTML.
+// The block is rendered as if it had no siblings
+func (c *Converter) RenderBlockToHTML(block *notionapi.Block) ([]byte, error) {
+	if c.NotionCompat {
+		c.UseKatexToRenderEquation = true
+	}
+	if c.UseKatexToRenderEquation {
+		if err := c.detectKatex(); err != nil {
+			return nil, err
+		}
+	}
+
+	currIdx := c.CurrBlockIdx
+	currBlocks := c.CurrBlocks
+	c.CurrBlocks = []*notionapi.Block{block}
+	c.CurrBlockIdx = 0
+	c.PushNewBuffer()
+	c.RenderBlock(block)
+	buf := c.PopBuffer()
+	c.CurrBlockIdx = currIdx
+	c.CurrBlocks = currBlocks
+	if c.Minify {
+		return minifyHTML(buf.Bytes()), nil
+	}
+	return buf.Bytes(), nil
+}
+
+var (
+	reEmptyClassOrStyle = regexp.MustCompile(` (class|style)=""`)
+	reSpaceBetweenTags  = regexp.MustCompile(`>\s*\n\s*<`)
+	rePreformatted      = regexp.MustCompile(`(?s)<(pre|textarea)[\s>].*?</(pre|textarea)>`)
+	reTagName           = regexp.MustCompile(`^</?([a-zA-Z0-9]+)`)
+)
+
+// whitespace next to those tags is insignificant
+var blockLevelTags = map[string]bool{
+	"html": true, "head": true, "body": true, "meta": true, "link": true,
+	"title": true, "style": true, "script": true, "article": true,
+	"header": true, "section": true, "nav": true, "div": true, "p": true,
+	"h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true,
+	"ul": true, "ol": true, "li": true, "table": true, "thead": true,
+	"tbody": true, "tr": true, "td": true, "th": true, "col": true,
+	"colgroup": true, "blockquote": true, "figure": true,
+	"figcaption": true, "details": true, "summary": true, "hr": true,
+	"br": true, "pre": true, "video": true, "iframe": true,
+}
+
+// tagName returns lower-cased name of the tag that starts at s[0] ('<')
+func tagName(s []byte) string {
+	m := reTagName.FindSubmatch(s)
+	if m == nil {
+		return ""
+	}
+	return strings.ToLower(string(m[1]))
+}
+
+// collapseSpaceBetweenTags removes whitespace spanning lines between tags
+// when one of them is a block-level tag. Between inline tags (e.g. </a>
+// and <a>) the whitespace is rendered so it's collapsed to a single space
+func collapseSpaceBetweenTags(d []byte) []byte {
+	var res []byte
+	prev := 0
+	for _, loc := range reSpaceBetweenTags.FindAllIndex(d, -1) {
+		// loc[0] is '>' ending previous tag, loc[1]-1 is '<' starting next tag
+		start := bytes.LastIndexByte(d[:loc[0]], '<')
+		before := ""
+		if start >= 0 {
+			before = tagName(d[start:loc[0]])
+		}
+		after := tagName(d[loc[1]-1:])
+		res = append(res, d[prev:loc[0]+1]...)
+		if !blockLevelTags[before] && !blockLevelTags[after] {
+			res = append(res, ' ')
+		}
+		res = append(res, '<')
+		prev = loc[1]
+	}
+	return append(res, d[prev:]...)
+}
+
+// minifyHTML removes empty class and style attributes and whitespace
+// between tags that spans lines (which is insignificant next to block-level
+// tags). Quotes in text are escaped so attributes can only match inside tags
+func minifyHTML(d []byte) []byte {
+	d = reEmptyClassOrStyle.ReplaceAll(d, nil)
+	var res []byte
+	prev := 0
+	for _, loc := range rePreformatted.FindAllIndex(d, -1) {
+		res = append(res, collapseSpaceBetweenTags(d[prev:loc[0]])...)
+		res = append(res, d[loc[0]:loc[1]]...)
+		prev = loc[1]
+	}
+	res = append(res, collapseSpaceBetweenTags(d[prev:])...)
+	return res
+}
+
 // ToHTML converts a page to HTML
 func ToHTML(page *notionapi.Page) []byte {
 	r := NewConverter(page)
@@ -1,11 +1,181 @@
 package tohtml2
 
 import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/kjk/notionapi"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
+// testRecord is a record returned by our fake Notion API
+type testRecord struct {
+	table string
+	value map[string]interface{}
+}
+
+// tid returns a valid Notion id for a number
+func tid(n int) string {
+	return fmt.Sprintf("00000000-0000-0000-0000-%012d", n)
+}
+
+func title(s string) []interface{} {
+	return []interface{}{[]interface{}{s}}
+}
+
+func testBlock(id, blockType, parentID string, content ...string) *testRecord {
+	v := map[string]interface{}{
+		"id":           id,
+		"type":         blockType,
+		"alive":        true,
+		"parent_id":    parentID,
+		"parent_table": "block",
+		"properties":   map[string]interface{}{},
+	}
+	if len(content) > 0 {
+		v["content"] = content
+	}
+	return &testRecord{table: "block", value: v}
+}
+
+func (r *testRecord) prop(name string, v interface{}) *testRecord {
+	r.value["properties"].(map[string]interface{})[name] = v
+	return r
+}
+
+func (r *testRecord) title(s string) *testRecord {
+	return r.prop("title", title(s))
+}
+
+func (r *testRecord) format(name string, v interface{}) *testRecord {
+	f, ok := r.value["format"].(map[string]interface{})
+	if !ok {
+		f = map[string]interface{}{}
+		r.value["format"] = f
+	}
+	f[name] = v
+	return r
+}
+
+func (r *testRecord) set(name string, v interface{}) *testRecord {
+	r.value[name] = v
+	return r
+}
+
+func recordWithRole(v interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"role":  "reader",
+		"value": v,
+	}
+}
+
+func fakeNotionResponse(records []*testRecord, req *http.Request) interface{} {
+	idToRecord := map[string]*testRecord{}
+	recordMap := map[string]map[string]interface{}{}
+	for _, r := range records {
+		id := r.value["id"].(string)
+		if r.table == "block" {
+			idToRecord[id] = r
+		}
+		if recordMap[r.table] == nil {
+			recordMap[r.table] = map[string]interface{}{}
+		}
+		recordMap[r.table][id] = recordWithRole(r.value)
+	}
+
+	d, _ := ioutil.ReadAll(req.Body)
+	switch req.URL.Path {
+	case "/api/v3/getRecordValues":
+		var body struct {
+			Requests []struct {
+				ID string `json:"id"`
+			} `json:"requests"`
+		}
+		_ = json.Unmarshal(d, &body)
+		var results []interface{}
+		for _, r := range body.Requests {
+			rec := idToRecord[r.ID]
+			if rec == nil {
+				results = append(results, map[string]interface{}{"role": "none"})
+				continue
+			}
+			results = append(results, recordWithRole(rec.value))
+		}
+		return map[string]interface{}{"results": results}
+	case "/api/v3/loadPageChunk":
+		return map[string]interface{}{
+			"cursor":    map[string]interface{}{"stack": []interface{}{}},
+			"recordMap": recordMap,
+		}
+	case "/api/v3/queryCollection":
+		var body struct {
+			CollectionID string `json:"collectionId"`
+		}
+		_ = json.Unmarshal(d, &body)
+		ids := []string{}
+		rows := map[string]interface{}{}
+		for _, r := range records {
+			if r.table == "block" && r.value["parent_table"] == "collection" && r.value["parent_id"] == body.CollectionID {
+				id := r.value["id"].(string)
+				ids = append(ids, id)
+				rows[id] = recordWithRole(r.value)
+			}
+		}
+		return map[string]interface{}{
+			"result":    map[string]interface{}{"type": "table", "blockIds": ids},
+			"recordMap": map[string]interface{}{"block": rows},
+		}
+	}
+	return map[string]interface{}{}
+}
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
+
+// newTestPage builds a Page from records by faking responses of Notion API.
+// The first record is the root page
+func newTestPage(t *testing.T, records ...*testRecord) *notionapi.Page {
+	transport := func(req *http.Request) (*http.Response, error) {
+		rsp := fakeNotionResponse(records, req)
+		d, err := json.Marshal(rsp)
+		if err != nil {
+			return nil, err
+		}
+		return &http.Response{
+			StatusCode: 200,
+			Body:       ioutil.NopCloser(bytes.NewReader(d)),
+			Header:     http.Header{},
+		}, nil
+	}
+	client := &notionapi.Client{
+		HTTPClient: &http.Client{Transport: roundTripFunc(transport)},
+	}
+	page, err := client.DownloadPage(records[0].value["id"].(string))
+	require.NoError(t, err)
+	return page
+}
+
+func toHTML(t *testing.T, c *Converter) string {
+	d, err := c.ToHTML()
+	require.NoError(t, err)
+	return string(d)
+}
+
 func TestHTMLFileNameForPage(t *testing.T) {
 	tests := [][]string{
 		{"Blendle's Employee Handbook", "Blendle s Employee Handbook.html"},
@@ -15,3 +185,1584 @@ func TestHTMLFileNameForPage(t *testing.T) {
 		assert.Equal(t, test[1], got)
 	}
 }
+
+func TestRenderSubPage(t *testing.T) {
+	page := newTestPage(t,
+		testBlock(tid(1), notionapi.BlockPage, tid(100), tid(2), tid(3)).title("Root"),
+		testBlock(tid(2), notionapi.BlockPage, tid(1)).title("Child"),
+		testBlock(tid(3), notionapi.BlockPage, tid(4)).title("Other"),
+	)
+	s := toHTML(t, NewConverter(page))
+	assert.Contains(t, s, fmt.Sprintf(`<figure id="%s" class="notion-sub-page"><a href="Root/Child.html">Child</a></figure>`, tid(2)))
+	// a page that lives in another page
+	assert.Contains(t, s, fmt.Sprintf(`<figure id="%s" class="mention-page"><a href="https://www.notion.so/Other-%s">Other</a></figure>`, tid(3), notionapi.ToNoDashID(tid(3))))
+
+	// Notion's export doesn't distinguish sub-pages
+	c := NewConverter(page)
+	c.NotionCompat = true
+	c.PushNewBuffer()
+	c.RenderBlock(page.Root())
+	s = c.PopBuffer().String()
+	assert.Contains(t, s, fmt.Sprintf(`<figure id="%s" class="link-to-page">`, tid(2)))
+}
+
+func TestInlineSubPages(t *testing.T) {
+	parent := newTestPage(t,
+		testBlock(tid(1), notionapi.BlockPage, tid(100), tid(2)).title("Root"),
+		testBlock(tid(2), notionapi.BlockPage, tid(1)).title("Child"),
+	)
+	child := newTestPage(t,
+		testBlock(tid(2), notionapi.BlockPage, tid(1), tid(3)).title("Child"),
+		testBlock(tid(3), notionapi.BlockText, tid(2)).title("child text"),
+	)
+	c := NewConverter(parent)
+	c.Pages = []*notionapi.Page{parent, child}
+	c.InlineSubPages = true
+	s := toHTML(t, c)
+	exp := fmt.Sprintf(`<section id="%s" class="notion-sub-page-content"><h1 class="page-title">Child</h1><p id="%s">child text</p></section>`, tid(2), tid(3))
+	assert.Contains(t, s, exp)
+	assert.NotContains(t, s, "notion-sub-page\"")
+	// rendering again gives the same result
+	assert.Equal(t, s, toHTML(t, c))
+
+	// without the sub-page in Pages we fall back to a link
+	c = NewConverter(parent)
+	c.InlineSubPages = true
+	s = toHTML(t, c)
+	assert.Contains(t, s, `class="notion-sub-page"`)
+}
+
+func TestRenderSimpleTable(t *testing.T) {
+	page := newTestPage(t,
+		testBlock(tid(1), notionapi.BlockPage, tid(100), tid(2)).title("Root"),
+		testBlock(tid(2), notionapi.BlockTable, tid(1), tid(3), tid(4)).
+			format("table_block_column_order", []string{"col1", "col2"}).
+			format("table_block_column_header", true),
+		testBlock(tid(3), notionapi.BlockTableRow, tid(2)).
+			prop("col1", title("Name")).
+			prop("col2", title("Age")),
+		testBlock(tid(4), notionapi.BlockTableRow, tid(2)).
+			prop("col1", title("Alice")).
+			prop("col2", title("32")),
+	)
+	s := toHTML(t, NewConverter(page))
+	exp := fmt.Sprintf(`<table id="%s" class="simple-table"><tbody>`, tid(2)) +
+		fmt.Sprintf(`<tr id="%s"><th>Name</th><th>Age</th></tr>`, tid(3)) +
+		fmt.Sprintf(`<tr id="%s"><td>Alice</td><td>32</td></tr>`, tid(4)) +
+		`</tbody></table>`
+	assert.Contains(t, s, exp)
+}
+
+func renderInline(c *Converter, ts *notionapi.TextSpan) string {
+	c.PushNewBuffer()
+	c.RenderInline(ts)
+	return c.PopBuffer().String()
+}
+
+func TestRenderInlinesMergesSpans(t *testing.T) {
+	c := NewConverter(nil)
+	bold := []notionapi.TextAttr{{notionapi.AttrBold}}
+	spans := []*notionapi.TextSpan{
+		{Text: "foo ", Attrs: bold},
+		{Text: "bar ", Attrs: bold},
+		{Text: "baz", Attrs: bold},
+		{Text: "!"},
+	}
+	c.PushNewBuffer()
+	c.RenderInlines(spans)
+	s := c.PopBuffer().String()
+	assert.Equal(t, `<strong>foo bar baz</strong>!`, s)
+	// input spans are not modified
+	assert.Equal(t, "foo ", spans[0].Text)
+
+	// mentions replace the text so they're never merged
+	userID := "bb760e2d-d679-4b64-b2a9-03005b21870a"
+	user := []notionapi.TextAttr{{notionapi.AttrUser, userID}}
+	spans = []*notionapi.TextSpan{
+		{Text: notionapi.TextSpanSpecial, Attrs: user},
+		{Text: notionapi.TextSpanSpecial, Attrs: user},
+	}
+	assert.Len(t, mergeTextSpans(spans), 2)
+}
+
+func TestRenderLinkMention(t *testing.T) {
+	c := NewConverter(nil)
+	ts := &notionapi.TextSpan{
+		Text: notionapi.TextSpanSpecial,
+		Attrs: []notionapi.TextAttr{
+			{notionapi.AttrLinkMention, `{"href":"https://github.com/kjk/notionapi","title":"kjk/notionapi","icon_url":"https://github.com/favicon.ico"}`},
+		},
+	}
+	exp := `<span class="notion-link-mention"><a href="https://github.com/kjk/notionapi"><img class="icon" src="https://github.com/favicon.ico"/>kjk/notionapi</a></span>`
+	assert.Equal(t, exp, renderInline(c, ts))
+
+	// without a title it's a plain link
+	ts.Attrs[0][1] = `{"href":"https://github.com/kjk/notionapi"}`
+	exp = `<a href="https://github.com/kjk/notionapi">https://github.com/kjk/notionapi</a>`
+	assert.Equal(t, exp, renderInline(c, ts))
+}
+
+func TestBodyOnly(t *testing.T) {
+	page := newTestPage(t,
+		testBlock(tid(1), notionapi.BlockPage, tid(100), tid(2), tid(3)).title("Root"),
+		testBlock(tid(2), notionapi.BlockHeader, tid(1)).title("Header"),
+		testBlock(tid(3), notionapi.BlockText, tid(1)).title("text"),
+	)
+	c := NewConverter(page)
+	c.BodyOnly = true
+	c.AddHeaderAnchor = true
+	s := toHTML(t, c)
+	assert.NotContains(t, s, "<article")
+	assert.NotContains(t, s, "page-body")
+	assert.NotContains(t, s, "<header>")
+	assert.Contains(t, s, fmt.Sprintf(`<h1 id="%s"><a class="notion-header-anchor" href="#%s"`, tid(2), tid(2)))
+	assert.Contains(t, s, fmt.Sprintf(`<p id="%s">text</p>`, tid(3)))
+
+	c = NewConverter(page)
+	s = toHTML(t, c)
+	assert.Contains(t, s, "<article")
+}
+
+func TestNormalizeNotionURL(t *testing.T) {
+	tests := [][]string{
+		{"/images/page-cover/gradients_11.jpg", "https://www.notion.so/images/page-cover/gradients_11.jpg"},
+		{"https://example.com/foo.png", "https://example.com/foo.png"},
+		{"//example.com/foo.png", "//example.com/foo.png"},
+		{"", ""},
+	}
+	for _, test := range tests {
+		got := normalizeNotionURL(test[0])
+		assert.Equal(t, test[1], got)
+	}
+}
+
+func TestRenderImageRelativeSource(t *testing.T) {
+	page := newTestPage(t,
+		testBlock(tid(1), notionapi.BlockPage, tid(100), tid(2), tid(3)).title("Root"),
+		testBlock(tid(2), notionapi.BlockImage, tid(1)).prop("source", title("/image/foo.png")),
+		testBlock(tid(3), notionapi.BlockImage, tid(1)).prop("source", title("https://example.com/bar.png")),
+	)
+	s := toHTML(t, NewConverter(page))
+	assert.Contains(t, s, `<img src="https://www.notion.so/image/foo.png"/>`)
+	assert.Contains(t, s, `<img src="https://example.com/bar.png"/>`)
+}
+
+func TestRenderInlineEquationInCollection(t *testing.T) {
+	equation := func(eq string) []interface{} {
+		return []interface{}{[]interface{}{"⁍", []interface{}{[]interface{}{"e", eq}}}}
+	}
+	collection := &testRecord{table: "collection", value: map[string]interface{}{
+		"id":    tid(10),
+		"alive": true,
+		"name":  title("Formulas"),
+		"schema": map[string]interface{}{
+			"title": map[string]interface{}{"name": "Name", "type": "title"},
+			"eq":    map[string]interface{}{"name": "Equation", "type": "text"},
+		},
+	}}
+	view := &testRecord{table: "collection_view", value: map[string]interface{}{
+		"id":    tid(11),
+		"alive": true,
+		"type":  "table",
+		"format": map[string]interface{}{
+			"table_properties": []interface{}{
+				map[string]interface{}{"property": "title", "visible": true},
+				map[string]interface{}{"property": "eq", "visible": true},
+			},
+		},
+	}}
+	page := newTestPage(t,
+		testBlock(tid(1), notionapi.BlockPage, "", tid(2)).title("Page"),
+		testBlock(tid(2), notionapi.BlockCollectionView, tid(1)).
+			set("collection_id", tid(10)).set("view_ids", []string{tid(11)}),
+		collection,
+		view,
+		&testRecord{table: "notion_user", value: map[string]interface{}{"id": tid(20)}},
+		testBlock(tid(3), notionapi.BlockPage, tid(10)).set("parent_table", "collection").
+			title("Pythagoras").prop("eq", equation("a^2+b^2=c^2")),
+		testBlock(tid(4), notionapi.BlockPage, tid(10)).set("parent_table", "collection").
+			title("Euler").prop("eq", equation("e^{i\\pi}+1=0")),
+	)
+
+	c := NewConverter(page)
+	s := toHTML(t, c)
+	assert.Contains(t, s, `<td class="cell-eq"><span class="notion-text-equation-token">a^2+b^2=c^2</span></td>`)
+
+	if runtime.GOOS == "windows" {
+		return
+	}
+	// fake katex binary so that we don't depend on it being installed
+	dir, err := ioutil.TempDir("", "notionapi-katex")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	katexPath := filepath.Join(dir, "katex")
+	script := "#!/bin/sh\ncat >/dev/null\necho '<span class=\"katex\">eq</span>'\n"
+	err = ioutil.WriteFile(katexPath, []byte(script), 0755)
+	require.NoError(t, err)
+
+	c = NewConverter(page)
+	c.UseKatexToRenderEquation = true
+	c.KatexPath = katexPath
+	s = toHTML(t, c)
+	assert.Contains(t, s, `<td class="cell-eq"><style>@import`)
+	assert.Equal(t, 2, strings.Count(s, `<span class="katex">eq</span>`))
+	assert.Equal(t, 1, strings.Count(s, "katex.min.css"))
+}
+
+func TestVideoDimensions(t *testing.T) {
+	page := newTestPage(t,
+		testBlock(tid(1), notionapi.BlockPage, "", tid(2), tid(3)).title("Page"),
+		testBlock(tid(2), notionapi.BlockVideo, tid(1)).
+			format("block_width", 640).format("block_aspect_ratio", 0.5625),
+		testBlock(tid(3), notionapi.BlockVideo, tid(1)).
+			format("block_width", 400).format("block_height", 300),
+	)
+	width, aspectRatio := videoDimensions(page.BlockByID(tid(2)))
+	assert.Equal(t, 640, width)
+	assert.Equal(t, 0.5625, aspectRatio)
+
+	// aspect ratio is calculated from height if not given
+	width, aspectRatio = videoDimensions(page.BlockByID(tid(3)))
+	assert.Equal(t, 400, width)
+	assert.Equal(t, 0.75, aspectRatio)
+}
+
+func TestRenderVideoPlayer(t *testing.T) {
+	page := newTestPage(t,
+		testBlock(tid(1), notionapi.BlockPage, "", tid(2), tid(3)).title("Page"),
+		testBlock(tid(2), notionapi.BlockVideo, tid(1)).
+			prop("source", title("https://example.com/clip.mp4")).
+			format("block_width", 640).format("block_aspect_ratio", 0.5625),
+		testBlock(tid(3), notionapi.BlockVideo, tid(1)).
+			prop("source", title("https://www.youtube.com/watch?v=abc")),
+	)
+	s := toHTML(t, NewConverter(page))
+	exp := `<video class="notion-video" controls preload="metadata" src="https://example.com/clip.mp4" style="width:640px;max-width:100%;aspect-ratio:1/0.5625;"></video>`
+	assert.Contains(t, s, fmt.Sprintf(`<figure id="%s">%s<div class="source">`, tid(2), exp))
+	// not a video file, only a link
+	assert.Equal(t, 1, strings.Count(s, "<video"))
+
+	c := NewConverter(page)
+	c.NotionCompat = true
+	c.PushNewBuffer()
+	c.RenderBlock(page.Root())
+	assert.NotContains(t, c.PopBuffer().String(), "<video")
+}
+
+func TestVideoPoster(t *testing.T) {
+	page := newTestPage(t,
+		testBlock(tid(1), notionapi.BlockPage, "", tid(2), tid(3), tid(4)).title("Page"),
+		testBlock(tid(2), notionapi.BlockVideo, tid(1)).
+			format("block_thumbnail", "https://example.com/thumb.jpg").
+			format("block_cover", "https://example.com/cover.jpg"),
+		testBlock(tid(3), notionapi.BlockVideo, tid(1)).
+			format("block_cover", "https://example.com/cover.jpg"),
+		testBlock(tid(4), notionapi.BlockVideo, tid(1)),
+	)
+	assert.Equal(t, "https://example.com/thumb.jpg", videoPoster(page.BlockByID(tid(2))))
+	assert.Equal(t, "https://example.com/cover.jpg", videoPoster(page.BlockByID(tid(3))))
+	assert.Equal(t, "", videoPoster(page.BlockByID(tid(4))))
+
+	page = newTestPage(t,
+		testBlock(tid(1), notionapi.BlockPage, "", tid(2)).title("Page"),
+		testBlock(tid(2), notionapi.BlockVideo, tid(1)).
+			prop("source", title("https://example.com/clip.mp4")).
+			format("block_thumbnail", "https://example.com/thumb.jpg?a=1&b=2"),
+	)
+	s := toHTML(t, NewConverter(page))
+	exp := `<video class="notion-video" controls preload="metadata" src="https://example.com/clip.mp4" poster="https://example.com/thumb.jpg?a=1&amp;b=2"></video>`
+	assert.Contains(t, s, exp)
+}
+
+func TestRenderToggleOpenState(t *testing.T) {
+	page := newTestPage(t,
+		testBlock(tid(1), notionapi.BlockPage, "", tid(2), tid(3)).title("Page"),
+		testBlock(tid(2), notionapi.BlockToggle, tid(1)).title("collapsed"),
+		testBlock(tid(3), notionapi.BlockToggle, tid(1)).title("expanded").
+			format("toggle_open", true),
+	)
+	c := NewConverter(page)
+	s := toHTML(t, c)
+	assert.Contains(t, s, `<details><summary>collapsed</summary>`)
+	assert.Contains(t, s, `<details open=""><summary>expanded</summary>`)
+
+	c = NewConverter(page)
+	c.ExpandAllToggles = true
+	s = toHTML(t, c)
+	assert.Contains(t, s, `<details open=""><summary>collapsed</summary>`)
+	assert.Contains(t, s, `<details open=""><summary>expanded</summary>`)
+}
+
+func TestRenderFile(t *testing.T) {
+	source := "https://s3-us-west-2.amazonaws.com/secure.notion-static.com/4c5e/Annual%20Report.pdf"
+	page := newTestPage(t,
+		testBlock(tid(1), notionapi.BlockPage, "", tid(2)).title("Page"),
+		testBlock(tid(2), notionapi.BlockFile, tid(1)).
+			prop("source", title(source)).prop("size", title("1.2MB")),
+	)
+	c := NewConverter(page)
+	s := toHTML(t, c)
+	exp := `<a class="notion-file" href="Page/Annual%20Report.pdf"><span class="notion-file-icon">📄</span>` +
+		`<span class="notion-file-name">Annual Report.pdf</span><span class="notion-file-size">1.2MB</span></a>`
+	assert.Contains(t, s, exp)
+	assert.NotContains(t, s, `>`+source+`<`)
+}
+
+func TestRenderBookmark(t *testing.T) {
+	page := newTestPage(t,
+		testBlock(tid(1), notionapi.BlockPage, "", tid(2), tid(3)).title("Page"),
+		testBlock(tid(2), notionapi.BlockBookmark, tid(1)).title("Notion API").
+			prop("link", title("https://github.com/kjk/notionapi")).
+			prop("description", title("Unofficial Go API for Notion")).
+			format("bookmark_icon", "https://github.com/favicon.ico").
+			format("bookmark_cover", "https://github.com/cover.png"),
+		testBlock(tid(3), notionapi.BlockBookmark, tid(1)).title("Blog").
+			prop("link", title("https://blog.kowalczyk.info")),
+	)
+	c := NewConverter(page)
+	s := toHTML(t, c)
+	exp := fmt.Sprintf(`<figure id="%s"><a href="https://github.com/kjk/notionapi" class="bookmark source">`, tid(2)) +
+		`<div class="bookmark-info"><div class="bookmark-text"><div class="bookmark-title">Notion API</div>` +
+		`<div class="bookmark-description">Unofficial Go API for Notion</div></div>` +
+		`<div class="bookmark-href"><img src="https://github.com/favicon.ico" class="icon bookmark-icon"/>https://github.com/kjk/notionapi</div></div>` +
+		`<img src="https://github.com/cover.png" class="bookmark-image"/></a></figure>`
+	assert.Contains(t, s, exp)
+
+	// without metadata it's a simple bookmark
+	exp = fmt.Sprintf(`<figure id="%s"><div class="bookmark source"><a href="https://blog.kowalczyk.info">Blog</a><br/>`, tid(3))
+	assert.Contains(t, s, exp)
+}
+
+func TestRenderCodeLanguageClass(t *testing.T) {
+	assert.Equal(t, "language-cpp", DefaultLanguageClass("C++"))
+	assert.Equal(t, "language-csharp", DefaultLanguageClass("c#"))
+	assert.Equal(t, "language-bash", DefaultLanguageClass("Shell"))
+	assert.Equal(t, "language-go", DefaultLanguageClass("Go"))
+	assert.Equal(t, "", DefaultLanguageClass("Plain Text"))
+
+	page := newTestPage(t,
+		testBlock(tid(1), notionapi.BlockPage, "", tid(2)).title("Page"),
+		testBlock(tid(2), notionapi.BlockCode, tid(1)).title("int main() {}").
+			prop("language", title("C++")),
+	)
+	c := NewConverter(page)
+	s := toHTML(t, c)
+	assert.Contains(t, s, `<code class="language-cpp">int main() {}</code>`)
+
+	c = NewConverter(page)
+	c.LanguageClass = func(notionLang string) string {
+		return "hljs " + strings.ToLower(notionLang)
+	}
+	s = toHTML(t, c)
+	assert.Contains(t, s, `<code class="hljs c++">int main() {}</code>`)
+}
+
+func TestConverterReset(t *testing.T) {
+	page1 := newTestPage(t,
+		testBlock(tid(1), notionapi.BlockPage, "", tid(2), tid(3)).title("Page 1"),
+		testBlock(tid(2), notionapi.BlockNumberedList, tid(1)).title("one"),
+		testBlock(tid(3), notionapi.BlockNumberedList, tid(1)).title("two"),
+	)
+	page2 := newTestPage(t,
+		testBlock(tid(11), notionapi.BlockPage, "", tid(12)).title("Page 2"),
+		testBlock(tid(12), notionapi.BlockNumberedList, tid(11)).title("first"),
+	)
+
+	c := NewConverter(page1)
+	c.BodyOnly = true
+	s1 := toHTML(t, c)
+	assert.Contains(t, s1, "two")
+
+	c.Reset(page2)
+	assert.True(t, c.BodyOnly)
+	s2 := toHTML(t, c)
+
+	fresh := NewConverter(page2)
+	fresh.BodyOnly = true
+	assert.Equal(t, toHTML(t, fresh), s2)
+	assert.NotContains(t, s2, "Page 1")
+}
+
+func TestSlugIDs(t *testing.T) {
+	page := newTestPage(t,
+		testBlock(tid(1), notionapi.BlockPage, "", tid(2), tid(3), tid(4)).title("Page"),
+		testBlock(tid(2), notionapi.BlockTableOfContents, tid(1)),
+		testBlock(tid(3), notionapi.BlockHeader, tid(1)).title("My Section!"),
+		testBlock(tid(4), notionapi.BlockSubHeader, tid(1)).title("My section"),
+	)
+	c := NewConverter(page)
+	c.SlugIDs = true
+	s := toHTML(t, c)
+	assert.Contains(t, s, `<h1 id="my-section">My Section!</h1>`)
+	assert.Contains(t, s, `<h2 id="my-section-1">My section</h2>`)
+	assert.Contains(t, s, `<a class="table_of_contents-link" href="#my-section">My Section!</a>`)
+	assert.Contains(t, s, `<a class="table_of_contents-link" href="#my-section-1">My section</a>`)
+	assert.Equal(t, "my-section-1", c.AnchorID(page.BlockByID(tid(4))))
+
+	assert.Equal(t, "zażółć-gęślą", slugify("Zażółć  gęślą?"))
+}
+
+func TestSlugIDsBlockLinks(t *testing.T) {
+	link := func(text, uri string) []interface{} {
+		return []interface{}{text, []interface{}{[]interface{}{"a", uri}}}
+	}
+	spans := []interface{}{
+		link("second", "https://www.notion.so/Page-"+notionapi.ToNoDashID(tid(1))+"#"+notionapi.ToNoDashID(tid(4))),
+		link("first", "#"+notionapi.ToNoDashID(tid(3))),
+		link("other page", "https://www.notion.so/Other-"+notionapi.ToNoDashID(tid(9))+"#"+notionapi.ToNoDashID(tid(3))),
+	}
+	page := newTestPage(t,
+		// a link to the second header is rendered before both headers
+		testBlock(tid(1), notionapi.BlockPage, "", tid(2), tid(3), tid(4)).title("Page"),
+		testBlock(tid(2), notionapi.BlockText, tid(1)).prop("title", spans),
+		testBlock(tid(3), notionapi.BlockHeader, tid(1)).title("Intro"),
+		testBlock(tid(4), notionapi.BlockHeader, tid(1)).title("Intro"),
+	)
+	c := NewConverter(page)
+	c.SlugIDs = true
+	s := toHTML(t, c)
+	assert.Contains(t, s, `<a href="#intro-1">second</a>`)
+	assert.Contains(t, s, `<a href="#intro">first</a>`)
+	assert.Contains(t, s, `<h1 id="intro">Intro</h1><h1 id="intro-1">Intro</h1>`)
+	assert.Contains(t, s, `<a href="https://www.notion.so/Other-`)
+}
+
+func TestRenderCallout(t *testing.T) {
+	page := newTestPage(t,
+		testBlock(tid(1), notionapi.BlockPage, "", tid(2), tid(4)).title("Page"),
+		testBlock(tid(2), notionapi.BlockCallout, tid(1), tid(3)).title("Note").
+			format("block_color", "yellow_background").format("page_icon", "💡"),
+		testBlock(tid(3), notionapi.BlockText, tid(2)).title("nested"),
+		testBlock(tid(4), notionapi.BlockCallout, tid(1)).title("Plain"),
+	)
+	c := NewConverter(page)
+	s := toHTML(t, c)
+	exp := fmt.Sprintf(`<figure class="block-color-yellow_background callout" style="white-space:pre-wrap;display:flex" id="%s">`, tid(2)) +
+		`<div style="font-size:1.5em"><span class="icon">💡</span></div>` +
+		fmt.Sprintf(`<div style="width:100%%">Note<p id="%s">nested</p></div></figure>`, tid(3))
+	assert.Contains(t, s, exp)
+
+	exp = fmt.Sprintf(`<figure class="callout" style="white-space:pre-wrap;display:flex;border:1px solid rgba(55,53,47,0.16)" id="%s">`, tid(4))
+	assert.Contains(t, s, exp)
+}
+
+func TestRenderCalloutImageIcon(t *testing.T) {
+	page := newTestPage(t,
+		testBlock(tid(1), notionapi.BlockPage, "", tid(2)).title("Page"),
+		testBlock(tid(2), notionapi.BlockCallout, tid(1)).title("Note").
+			format("page_icon", "https://example.com/icon.png"),
+	)
+	s := toHTML(t, NewConverter(page))
+	assert.Contains(t, s, `<div style="font-size:1.5em"><img class="icon" src="https://example.com/icon.png"/></div>`)
+}
+
+func TestRenderCalloutWithList(t *testing.T) {
+	page := newTestPage(t,
+		testBlock(tid(1), notionapi.BlockPage, "", tid(2)).title("Page"),
+		testBlock(tid(2), notionapi.BlockCallout, tid(1), tid(3), tid(4)).title("Todo:"),
+		testBlock(tid(3), notionapi.BlockBulletedList, tid(2)).title("milk"),
+		testBlock(tid(4), notionapi.BlockBulletedList, tid(2)).title("eggs"),
+	)
+	c := NewConverter(page)
+	s := toHTML(t, c)
+	// the list must be inside content div so that it's the second flex item
+	idx := strings.Index(s, `<div style="width:100%">Todo:`)
+	require.True(t, idx > 0)
+	content := s[idx:]
+	end := strings.Index(content, `</div></figure>`)
+	require.True(t, end > 0)
+	content = content[:end]
+	assert.Contains(t, content, "milk")
+	assert.Contains(t, content, "eggs")
+	assert.Contains(t, content, `<ul id="`+tid(3)+`"`)
+}
+
+func TestDropComments(t *testing.T) {
+	bold := notionapi.TextAttr{notionapi.AttrBold}
+	comment := notionapi.TextAttr{notionapi.AttrComment, "00000000-0000-0000-0000-0000000000aa"}
+	spans := []*notionapi.TextSpan{
+		{Text: "reviewed ", Attrs: []notionapi.TextAttr{bold}},
+		{Text: "text", Attrs: []notionapi.TextAttr{bold, comment}},
+	}
+	render := func(c *Converter) string {
+		c.PushNewBuffer()
+		c.RenderInlines(spans)
+		return c.PopBuffer().String()
+	}
+	c := NewConverter(nil)
+	assert.Equal(t, `<strong>reviewed </strong><strong>text</strong>`, render(c))
+
+	c.DropComments = true
+	s := render(c)
+	assert.Equal(t, `<strong>reviewed text</strong>`, s)
+	assert.NotContains(t, s, comment[1])
+	// spans are not modified
+	assert.Len(t, spans[1].Attrs, 2)
+}
+
+func TestRenderEmptyPage(t *testing.T) {
+	page := newTestPage(t,
+		testBlock(tid(1), notionapi.BlockPage, ""),
+	)
+	c := NewConverter(page)
+	s := toHTML(t, c)
+	exp := fmt.Sprintf(`<article id="%s" class="page sans"><header><h1 class="page-title"></h1></header><div class="page-body"></div></article>`, tid(1))
+	assert.Equal(t, exp, s)
+
+	c = NewConverter(page)
+	c.FullHTML = true
+	s = toHTML(t, c)
+	assert.True(t, strings.HasPrefix(s, `<html><head>`))
+	assert.Contains(t, s, `<title></title>`)
+	assert.True(t, strings.HasSuffix(s, `</article></body></html>`))
+}
+
+func TestClassPrefix(t *testing.T) {
+	page := newTestPage(t,
+		testBlock(tid(1), notionapi.BlockPage, "", tid(2), tid(3), tid(4), tid(5)).title("Page"),
+		testBlock(tid(2), notionapi.BlockBulletedList, tid(1)).title("item"),
+		testBlock(tid(3), notionapi.BlockText, tid(1)).title("red").format("block_color", "red"),
+		testBlock(tid(4), notionapi.BlockCallout, tid(1)).title("note").format("page_icon", "💡"),
+		testBlock(tid(5), notionapi.BlockCode, tid(1)).title("x := 1").prop("language", title("Go")),
+	)
+	c := NewConverter(page)
+	c.ClassPrefix = "nt-"
+	s := toHTML(t, c)
+	assert.Contains(t, s, `class="nt-page nt-sans"`)
+	assert.Contains(t, s, `class="nt-page-title"`)
+	assert.Contains(t, s, `class="nt-bulleted-list"`)
+	assert.Contains(t, s, `class="nt-block-color-red"`)
+	assert.Contains(t, s, `class="nt-callout"`)
+	assert.Contains(t, s, `<span class="nt-icon">💡</span>`)
+	assert.Contains(t, s, `<pre id="`+tid(5)+`" class="nt-code"><code class="language-go">`)
+
+	// every class name must have the prefix
+	classes := regexp.MustCompile(`class="([^"]*)"`).FindAllStringSubmatch(s, -1)
+	require.NotEmpty(t, classes)
+	for _, m := range classes {
+		for _, name := range strings.Fields(m[1]) {
+			if strings.HasPrefix(name, "language-") {
+				continue
+			}
+			assert.True(t, strings.HasPrefix(name, "nt-"), "class '%s' has no prefix", name)
+		}
+	}
+}
+
+func TestFormatDateTimeElement(t *testing.T) {
+	c := NewConverter(nil)
+	d := &notionapi.Date{
+		Type:       notionapi.DateTypeDate,
+		StartDate:  "2021-03-04",
+		DateFormat: "YYYY/MM/DD",
+	}
+	assert.Equal(t, `<time datetime="2021-03-04">@2021/03/04</time>`, c.FormatDate(d))
+
+	d.Type = notionapi.DateTypeDateTime
+	d.StartTime = "10:00"
+	assert.Equal(t, `<time datetime="2021-03-04T10:00:00Z">@2021/03/04 10:00 AM</time>`, c.FormatDate(d))
+
+	// malformed date is escaped
+	d = &notionapi.Date{
+		Type:      notionapi.DateTypeDate,
+		StartDate: `2021"><script>`,
+	}
+	s := c.FormatDate(d)
+	assert.NotContains(t, s, "<script>")
+	assert.Contains(t, s, `datetime="2021&quot;&gt;&lt;script&gt;"`)
+}
+
+func TestFormatDateReminder(t *testing.T) {
+	c := NewConverter(nil)
+	d := &notionapi.Date{
+		Type:       notionapi.DateTypeDate,
+		StartDate:  "2021-03-04",
+		DateFormat: "YYYY/MM/DD",
+		Reminder:   &notionapi.Reminder{Time: "09:00", Unit: "day", Value: 1},
+	}
+	// by default reminders are not shown
+	assert.Equal(t, `<time datetime="2021-03-04">@2021/03/04</time>`, c.FormatDate(d))
+
+	c.ShowReminders = true
+	exp := `<time datetime="2021-03-04">@2021/03/04</time><span class="notion-reminder">⏰ 1 day before at 09:00</span>`
+	assert.Equal(t, exp, c.FormatDate(d))
+
+	assert.Equal(t, "2 hours before", formatReminder(&notionapi.Reminder{Unit: "hour", Value: 2}))
+	assert.Equal(t, "on day of event at 09:00", formatReminder(&notionapi.Reminder{Time: "09:00", Unit: "day"}))
+}
+
+func TestShowLockIndicator(t *testing.T) {
+	page := newTestPage(t,
+		testBlock(tid(1), notionapi.BlockPage, "").title("Page").format("block_locked", true),
+	)
+	lock := `<span class="notion-lock-indicator" title="Locked">🔒</span>`
+	c := NewConverter(page)
+	assert.NotContains(t, toHTML(t, c), lock)
+
+	c = NewConverter(page)
+	c.ShowLockIndicator = true
+	assert.Contains(t, toHTML(t, c), `Page`+lock+`</h1>`)
+
+	page = newTestPage(t,
+		testBlock(tid(1), notionapi.BlockPage, "").title("Page"),
+	)
+	c = NewConverter(page)
+	c.ShowLockIndicator = true
+	assert.NotContains(t, toHTML(t, c), lock)
+}
+
+func TestRenderNestedColumns(t *testing.T) {
+	page := newTestPage(t,
+		testBlock(tid(1), notionapi.BlockPage, "", tid(2)).title("Page"),
+		testBlock(tid(2), notionapi.BlockColumnList, tid(1), tid(3), tid(4)),
+		testBlock(tid(3), notionapi.BlockColumn, tid(2), tid(5)).format("column_ratio", 0.75),
+		testBlock(tid(4), notionapi.BlockColumn, tid(2)).format("column_ratio", 0.25),
+		// nested column list, ratios don't add up to 1
+		testBlock(tid(5), notionapi.BlockColumnList, tid(3), tid(6), tid(7)),
+		testBlock(tid(6), notionapi.BlockColumn, tid(5)).format("column_ratio", 0.375),
+		testBlock(tid(7), notionapi.BlockColumn, tid(5)).format("column_ratio", 0.375),
+	)
+	c := NewConverter(page)
+	assert.Equal(t, 50.0, c.columnWidthPercent(page.BlockByID(tid(6))))
+	s := toHTML(t, c)
+	colHTML := func(id string, width string) string {
+		return fmt.Sprintf(`<div id="%s" style="width:%s%%" class="column">`, id, width)
+	}
+	assert.Contains(t, s, colHTML(tid(3), "75"))
+	assert.Contains(t, s, colHTML(tid(4), "25"))
+	assert.Contains(t, s, colHTML(tid(6), "50"))
+	assert.Contains(t, s, colHTML(tid(7), "50"))
+
+	// columns without ratio share the width equally
+	page = newTestPage(t,
+		testBlock(tid(1), notionapi.BlockPage, "", tid(2)).title("Page"),
+		testBlock(tid(2), notionapi.BlockColumnList, tid(1), tid(3), tid(4), tid(5), tid(6)),
+		testBlock(tid(3), notionapi.BlockColumn, tid(2)),
+		testBlock(tid(4), notionapi.BlockColumn, tid(2)),
+		testBlock(tid(5), notionapi.BlockColumn, tid(2)),
+		testBlock(tid(6), notionapi.BlockColumn, tid(2)),
+	)
+	s = toHTML(t, NewConverter(page))
+	assert.Contains(t, s, colHTML(tid(5), "25"))
+}
+
+func TestMinify(t *testing.T) {
+	page := newTestPage(t,
+		testBlock(tid(1), notionapi.BlockPage, "", tid(2), tid(3)).title("Page"),
+		testBlock(tid(2), notionapi.BlockQuote, tid(1)).title("quote"),
+		testBlock(tid(3), notionapi.BlockText, tid(1)).title("text"),
+	)
+	c := NewConverter(page)
+	c.Minify = true
+	s := toHTML(t, c)
+	assert.NotContains(t, s, `class=""`)
+	assert.Contains(t, s, fmt.Sprintf(`<blockquote id="%s">quote</blockquote>`, tid(2)))
+	assert.Contains(t, s, fmt.Sprintf(`<p id="%s">text</p>`, tid(3)))
+}
+
+func TestMinifyHTML(t *testing.T) {
+	s := "<div class=\"\" style=\"\">\n  <p>a <b>b</b></p>\n</div><pre class=\"\">x\n  <span>y</span>\n</pre>"
+	exp := "<div><p>a <b>b</b></p></div><pre>x\n  <span>y</span>\n</pre>"
+	assert.Equal(t, exp, string(minifyHTML([]byte(s))))
+
+	// whitespace between inline elements is rendered so it must stay
+	s = "<p>\n  <a href=\"x\">x</a>\n  <a href=\"y\">y</a>\n  <b>z</b>\n</p>"
+	exp = "<p><a href=\"x\">x</a> <a href=\"y\">y</a> <b>z</b></p>"
+	assert.Equal(t, exp, string(minifyHTML([]byte(s))))
+}
+
+func TestNoEmptyClassAttr(t *testing.T) {
+	page := newTestPage(t,
+		testBlock(tid(1), notionapi.BlockPage, "", tid(2), tid(3), tid(4), tid(5)).title("Page"),
+		testBlock(tid(2), notionapi.BlockText, tid(1)).title("text"),
+		testBlock(tid(3), notionapi.BlockHeader, tid(1)).title("heading"),
+		testBlock(tid(4), notionapi.BlockQuote, tid(1)).title("quote"),
+		testBlock(tid(5), notionapi.BlockText, tid(1)).title("red").format("block_color", "red"),
+	)
+	c := NewConverter(page)
+	s := toHTML(t, c)
+	assert.NotContains(t, s, `class=""`)
+	assert.Contains(t, s, fmt.Sprintf(`<p id="%s">text</p>`, tid(2)))
+	assert.Contains(t, s, fmt.Sprintf(`<h1 id="%s">heading</h1>`, tid(3)))
+	assert.Contains(t, s, fmt.Sprintf(`<blockquote id="%s">quote</blockquote>`, tid(4)))
+	assert.Contains(t, s, fmt.Sprintf(`<p id="%s" class="block-color-red">red</p>`, tid(5)))
+
+	c = NewConverter(page)
+	c.NotionCompat = true
+	assert.Equal(t, ` class=""`, c.classAttr(""))
+}
+
+func TestRenderPageMentionIcon(t *testing.T) {
+	page := newTestPage(t,
+		testBlock(tid(1), notionapi.BlockPage, "", tid(2), tid(3)).title("Page"),
+		testBlock(tid(2), notionapi.BlockPage, tid(1)).title("Party").format("page_icon", "🎉"),
+		testBlock(tid(3), notionapi.BlockPage, tid(1)).title("Plain"),
+	)
+	render := func(pageID string) string {
+		c := NewConverter(page)
+		c.PushNewBuffer()
+		c.RenderInline(&notionapi.TextSpan{
+			Text:  "‣",
+			Attrs: []notionapi.TextAttr{{notionapi.AttrPage, pageID}},
+		})
+		return c.PopBuffer().String()
+	}
+	exp := fmt.Sprintf(`<a href="https://www.notion.so/Party-%s"><span class="icon notion-page-mention-icon">🎉</span>Party</a>`, notionapi.ToNoDashID(tid(2)))
+	assert.Equal(t, exp, render(tid(2)))
+
+	exp = fmt.Sprintf(`<a href="https://www.notion.so/Plain-%s">Plain</a>`, notionapi.ToNoDashID(tid(3)))
+	assert.Equal(t, exp, render(tid(3)))
+}
+
+func TestRenderDivider(t *testing.T) {
+	page := newTestPage(t,
+		testBlock(tid(1), notionapi.BlockPage, "", tid(2), tid(3)).title("Page"),
+		testBlock(tid(2), notionapi.BlockDivider, tid(1)),
+		testBlock(tid(3), notionapi.BlockDivider, tid(1)).format("block_color", "gray"),
+	)
+	c := NewConverter(page)
+	s := toHTML(t, c)
+	assert.Contains(t, s, fmt.Sprintf(`<hr id="%s" class="notion-divider"/>`, tid(2)))
+	assert.Contains(t, s, fmt.Sprintf(`<hr id="%s" class="block-color-gray notion-divider"/>`, tid(3)))
+
+	c = NewConverter(page)
+	c.DividerTag = "div"
+	s = toHTML(t, c)
+	assert.Contains(t, s, fmt.Sprintf(`<div id="%s" class="notion-divider"></div>`, tid(2)))
+
+	c = NewConverter(page)
+	c.DividerTag = `img src=x onerror=alert(1)`
+	_, err := c.ToHTML()
+	assert.Error(t, err)
+}
+
+func TestRenderBacklinks(t *testing.T) {
+	page := newTestPage(t,
+		testBlock(tid(1), notionapi.BlockPage, "", tid(2)).title("Page"),
+		testBlock(tid(2), notionapi.BlockText, tid(1)).title("text"),
+	)
+	parent := &notionapi.Block{ID: tid(10), Type: notionapi.BlockPage, Title: "Notes"}
+	first := &notionapi.Block{ID: tid(11), Type: notionapi.BlockPage, Title: "First", Parent: parent}
+	second := &notionapi.Block{ID: tid(12), Type: notionapi.BlockPage, Title: "Second"}
+
+	c := NewConverter(page)
+	assert.NotContains(t, toHTML(t, c), "notion-backlinks")
+
+	c = NewConverter(page)
+	c.Backlinks = []*notionapi.Backlink{
+		{BlockID: tid(21), PageID: tid(11), Page: first},
+		{BlockID: tid(22), PageID: tid(12), Page: second},
+		// the same page is only shown once
+		{BlockID: tid(23), PageID: tid(12), Page: second},
+	}
+	s := toHTML(t, c)
+	exp := `<section class="notion-backlinks"><h2 class="notion-backlinks-title">Linked references</h2><ul>` +
+		`<li><a href="Notes/First.html">First</a></li>` +
+		`<li><a href="Second.html">Second</a></li>` +
+		`</ul></section></div></article>`
+	assert.Contains(t, s, exp)
+}
+
+// newTestCollectionPage returns a page with a table collection view block
+// tid(2) of collection tid(10) with a given schema and table_properties
+// of the view. rows are added as pages in the collection
+func newTestCollectionPage(t *testing.T, schema map[string]interface{}, tableProps []interface{}, rows ...*testRecord) *notionapi.Page {
+	view := map[string]interface{}{
+		"type":   "table",
+		"format": map[string]interface{}{"table_properties": tableProps},
+	}
+	return newTestCollectionPageWithView(t, schema, view, rows...)
+}
+
+// newTestCollectionPageWithView is like newTestCollectionPage but
+// the collection_view record is view (id and alive are set)
+func newTestCollectionPageWithView(t *testing.T, schema map[string]interface{}, view map[string]interface{}, rows ...*testRecord) *notionapi.Page {
+	collection := &testRecord{table: "collection", value: map[string]interface{}{
+		"id":     tid(10),
+		"alive":  true,
+		"name":   title("Table"),
+		"schema": schema,
+	}}
+	view["id"] = tid(11)
+	view["alive"] = true
+	records := []*testRecord{
+		testBlock(tid(1), notionapi.BlockPage, "", tid(2)).title("Page"),
+		testBlock(tid(2), notionapi.BlockCollectionView, tid(1)).
+			set("collection_id", tid(10)).set("view_ids", []string{tid(11)}),
+		collection,
+		&testRecord{table: "collection_view", value: view},
+		&testRecord{table: "notion_user", value: map[string]interface{}{"id": tid(20)}},
+	}
+	for _, row := range rows {
+		records = append(records, row.set("parent_id", tid(10)).set("parent_table", "collection"))
+	}
+	return newTestPage(t, records...)
+}
+
+func TestRenderCollectionHiddenColumns(t *testing.T) {
+	schema := map[string]interface{}{
+		"title": map[string]interface{}{"name": "Name", "type": "title"},
+		"shown": map[string]interface{}{"name": "Shown", "type": "text"},
+		"hide":  map[string]interface{}{"name": "Secret", "type": "text"},
+	}
+	tableProps := []interface{}{
+		map[string]interface{}{"property": "title", "visible": true},
+		map[string]interface{}{"property": "hide", "visible": false},
+		map[string]interface{}{"property": "shown", "visible": true},
+	}
+	page := newTestCollectionPage(t, schema, tableProps,
+		testBlock(tid(3), notionapi.BlockPage, "").title("Row").
+			prop("shown", title("visible value")).prop("hide", title("hidden value")),
+	)
+	s := toHTML(t, NewConverter(page))
+	assert.Contains(t, s, `<th>Name</th><th>Shown</th></tr>`)
+	assert.NotContains(t, s, "Secret")
+	assert.Contains(t, s, `<td class="cell-shown">visible value</td>`)
+	assert.NotContains(t, s, "cell-hide")
+	assert.NotContains(t, s, "hidden value")
+}
+
+func TestRenderCollectionColumnWidths(t *testing.T) {
+	schema := map[string]interface{}{
+		"title": map[string]interface{}{"name": "Name", "type": "title"},
+		"note":  map[string]interface{}{"name": "Note", "type": "text"},
+	}
+	tableProps := []interface{}{
+		map[string]interface{}{"property": "title", "visible": true, "width": 276},
+		map[string]interface{}{"property": "note", "visible": true},
+	}
+	page := newTestCollectionPage(t, schema, tableProps,
+		testBlock(tid(3), notionapi.BlockPage, "").title("Row"),
+	)
+	s := toHTML(t, NewConverter(page))
+	assert.Contains(t, s, `<table class="collection-content"><colgroup><col style="width:276px"/><col/></colgroup><thead>`)
+
+	// no widths, no <colgroup>
+	tableProps = []interface{}{
+		map[string]interface{}{"property": "title", "visible": true},
+		map[string]interface{}{"property": "note", "visible": true},
+	}
+	page = newTestCollectionPage(t, schema, tableProps,
+		testBlock(tid(3), notionapi.BlockPage, "").title("Row"),
+	)
+	s = toHTML(t, NewConverter(page))
+	assert.NotContains(t, s, `<colgroup>`)
+}
+
+func TestRenderCodeCopyButton(t *testing.T) {
+	page := newTestPage(t,
+		testBlock(tid(1), notionapi.BlockPage, "", tid(2)).title("Page"),
+		testBlock(tid(2), notionapi.BlockCode, tid(1)).title("x := 1").prop("language", title("Plain Text")),
+	)
+	c := NewConverter(page)
+	s := toHTML(t, c)
+	assert.NotContains(t, s, "code-copy")
+	pre := fmt.Sprintf(`<pre id="%s" class="code"><code>x := 1</code></pre>`, tid(2))
+	assert.Contains(t, s, pre)
+
+	c = NewConverter(page)
+	c.CodeCopyButton = true
+	s = toHTML(t, c)
+	exp := fmt.Sprintf(`<div class="code-wrapper"><button type="button" class="code-copy" data-clipboard-target="#%s">Copy</button>%s</div>`, tid(2), pre)
+	assert.Contains(t, s, exp)
+}
+
+func TestRenderCodeCaptionAndWrap(t *testing.T) {
+	page := newTestPage(t,
+		testBlock(tid(1), notionapi.BlockPage, "", tid(2), tid(3)).title("Page"),
+		testBlock(tid(2), notionapi.BlockCode, tid(1)).title("x := 1").
+			prop("language", title("Plain Text")).prop("caption", title("Example")),
+		testBlock(tid(3), notionapi.BlockCode, tid(1)).title("y := 2").
+			prop("language", title("Plain Text")).format("code_wrap", true),
+	)
+	s := toHTML(t, NewConverter(page))
+	exp := fmt.Sprintf(`<figure class="code-figure"><pre id="%s" class="code"><code>x := 1</code></pre><figcaption>Example</figcaption></figure>`, tid(2))
+	assert.Contains(t, s, exp)
+	exp = fmt.Sprintf(`<pre id="%s" class="code code-wrap"><code>y := 2</code></pre>`, tid(3))
+	assert.Contains(t, s, exp)
+	assert.Equal(t, 1, strings.Count(s, "<figure"))
+}
+
+func TestDataBlockID(t *testing.T) {
+	page := newTestPage(t,
+		testBlock(tid(1), notionapi.BlockPage, "", tid(2), tid(3), tid(4)).title("Page"),
+		testBlock(tid(2), notionapi.BlockText, tid(1)).title("text"),
+		testBlock(tid(3), notionapi.BlockHeader, tid(1)).title("My Section"),
+		testBlock(tid(4), notionapi.BlockToggle, tid(1), tid(5)).title("toggle"),
+		testBlock(tid(5), notionapi.BlockText, tid(4)).title("nested"),
+	)
+	c := NewConverter(page)
+	assert.NotContains(t, toHTML(t, c), "data-block-id")
+
+	c = NewConverter(page)
+	c.DataBlockID = true
+	c.SlugIDs = true
+	s := toHTML(t, c)
+	assert.Contains(t, s, fmt.Sprintf(`<article id="%s" data-block-id="%s" class="page sans">`, tid(1), tid(1)))
+	assert.Contains(t, s, fmt.Sprintf(`<p data-block-id="%s" id="%s">text</p>`, tid(2), tid(2)))
+	assert.Contains(t, s, fmt.Sprintf(`<h1 data-block-id="%s" id="my-section">`, tid(3)))
+	assert.Contains(t, s, fmt.Sprintf(`<ul data-block-id="%s" id="%s" class="toggle">`, tid(4), tid(4)))
+	assert.Contains(t, s, fmt.Sprintf(`<p data-block-id="%s" id="%s">nested</p>`, tid(5), tid(5)))
+
+	c = NewConverter(page)
+	c.DataBlockID = true
+	c.BodyOnly = true
+	s = toHTML(t, c)
+	assert.NotContains(t, s, fmt.Sprintf(`data-block-id="%s"`, tid(1)))
+	assert.Contains(t, s, fmt.Sprintf(`<p data-block-id="%s" id="%s">text</p>`, tid(2), tid(2)))
+}
+
+func TestInteractiveTodos(t *testing.T) {
+	page := newTestPage(t,
+		testBlock(tid(1), notionapi.BlockPage, "", tid(2), tid(3)).title("Page"),
+		testBlock(tid(2), notionapi.BlockTodo, tid(1)).title("done").prop("checked", title("Yes")),
+		testBlock(tid(3), notionapi.BlockTodo, tid(1)).title("todo"),
+	)
+	c := NewConverter(page)
+	s := toHTML(t, c)
+	assert.NotContains(t, s, "<input")
+	assert.Contains(t, s, `<div class="checkbox checkbox-on"></div>`)
+
+	c = NewConverter(page)
+	c.InteractiveTodos = true
+	s = toHTML(t, c)
+	assert.NotContains(t, s, `<div class="checkbox`)
+	assert.Contains(t, s, fmt.Sprintf(`<input type="checkbox" class="to-do-checkbox" data-block-id="%s" checked/>`, tid(2)))
+	assert.Contains(t, s, fmt.Sprintf(`<input type="checkbox" class="to-do-checkbox" data-block-id="%s"/>`, tid(3)))
+}
+
+func TestRenderCalloutIconBackground(t *testing.T) {
+	page := newTestPage(t,
+		testBlock(tid(1), notionapi.BlockPage, "", tid(2), tid(3)).title("Page"),
+		testBlock(tid(2), notionapi.BlockCallout, tid(1)).title("Note").
+			format("page_icon", "💡").format("page_icon_background", "red"),
+		testBlock(tid(3), notionapi.BlockCallout, tid(1)).title("Plain").format("page_icon", "💡"),
+	)
+	s := toHTML(t, NewConverter(page))
+	assert.Contains(t, s, `<div class="block-color-red_background callout-icon" style="font-size:1.5em"><span class="icon">💡</span></div><div style="width:100%">Note`)
+	assert.Contains(t, s, `<div style="font-size:1.5em"><span class="icon">💡</span></div><div style="width:100%">Plain`)
+}
+
+func TestTableOfContentsSkipsSubPages(t *testing.T) {
+	page := newTestPage(t,
+		testBlock(tid(1), notionapi.BlockPage, "", tid(2), tid(3), tid(4), tid(6)).title("Page"),
+		testBlock(tid(2), notionapi.BlockTableOfContents, tid(1)),
+		testBlock(tid(3), notionapi.BlockHeader, tid(1)).title("Intro"),
+		testBlock(tid(4), notionapi.BlockPage, tid(1), tid(5)).title("Sub page"),
+		testBlock(tid(5), notionapi.BlockHeader, tid(4)).title("Sub page header"),
+		testBlock(tid(6), notionapi.BlockToggle, tid(1), tid(7)).title("toggle"),
+		testBlock(tid(7), notionapi.BlockSubHeader, tid(6)).title("In toggle"),
+	)
+	s := toHTML(t, NewConverter(page))
+	i := strings.Index(s, "<nav")
+	toc := s[i : strings.Index(s[i:], "</nav>")+i]
+	assert.Contains(t, toc, "Intro")
+	assert.Contains(t, toc, "In toggle")
+	assert.NotContains(t, toc, "Sub page header")
+}
+
+func TestRenderQuoteSize(t *testing.T) {
+	page := newTestPage(t,
+		testBlock(tid(1), notionapi.BlockPage, "", tid(2), tid(3)).title("Page"),
+		testBlock(tid(2), notionapi.BlockQuote, tid(1)).title("default"),
+		testBlock(tid(3), notionapi.BlockQuote, tid(1)).title("large").format("quote_size", "large"),
+	)
+	s := toHTML(t, NewConverter(page))
+	assert.Contains(t, s, fmt.Sprintf(`<blockquote id="%s">default</blockquote>`, tid(2)))
+	assert.Contains(t, s, fmt.Sprintf(`<blockquote id="%s" class="quote-large">large</blockquote>`, tid(3)))
+}
+
+func TestShouldLocalizeAsset(t *testing.T) {
+	page := newTestPage(t,
+		testBlock(tid(1), notionapi.BlockPage, "", tid(2)).title("Page").
+			format("page_cover", "https://example.com/cover.jpg"),
+		testBlock(tid(2), notionapi.BlockImage, tid(1)).
+			prop("source", title("https://s3-us-west-2.amazonaws.com/secure.notion-static.com/x/img.png")).
+			set("file_ids", []string{"x"}),
+	)
+	c := NewConverter(page)
+	assert.True(t, c.shouldLocalizeAsset("https://s3-us-west-2.amazonaws.com/secure.notion-static.com/x/img.png"))
+	assert.False(t, c.shouldLocalizeAsset("https://example.com/cover.jpg"))
+	s := toHTML(t, c)
+	assert.Contains(t, s, `src="https://example.com/cover.jpg"`)
+	assert.Contains(t, s, `src="Page/img.png"`)
+
+	c = NewConverter(page)
+	c.ShouldLocalizeAsset = func(uri string) bool {
+		return strings.HasPrefix(uri, "https://example.com/")
+	}
+	s = toHTML(t, c)
+	assert.Contains(t, s, `src="Page/cover.jpg"`)
+	assert.Contains(t, s, `src="https://s3-us-west-2.amazonaws.com/secure.notion-static.com/x/img.png"`)
+}
+
+func TestIncludeExcludeBlockTypes(t *testing.T) {
+	page := newTestPage(t,
+		testBlock(tid(1), notionapi.BlockPage, "", tid(2), tid(3), tid(4), tid(6), tid(7)).title("Page"),
+		testBlock(tid(2), notionapi.BlockText, tid(1)).title("first"),
+		testBlock(tid(3), notionapi.BlockImage, tid(1)).prop("source", title("https://example.com/bar.png")),
+		testBlock(tid(4), notionapi.BlockToggle, tid(1), tid(5)).title("toggle"),
+		testBlock(tid(5), notionapi.BlockText, tid(4)).title("inside toggle"),
+		testBlock(tid(6), notionapi.BlockHeader, tid(1)).title("Section"),
+		testBlock(tid(7), notionapi.BlockPage, tid(1), tid(8)).title("Sub page"),
+		testBlock(tid(8), notionapi.BlockText, tid(7)).title("inside sub page"),
+	)
+
+	c := NewConverter(page)
+	c.IncludeBlockTypes = []string{notionapi.BlockText}
+	s := toHTML(t, c)
+	assert.Contains(t, s, "first")
+	assert.Contains(t, s, "inside toggle")
+	// content of a skipped sub-page is skipped too
+	assert.NotContains(t, s, "Sub page")
+	assert.NotContains(t, s, "inside sub page")
+	assert.NotContains(t, s, "<details")
+	assert.NotContains(t, s, "bar.png")
+	assert.NotContains(t, s, "Section")
+	// the page itself is always rendered
+	assert.Contains(t, s, "<article")
+
+	c = NewConverter(page)
+	c.ExcludeBlockTypes = []string{notionapi.BlockImage, notionapi.BlockToggle}
+	s = toHTML(t, c)
+	assert.Contains(t, s, "first")
+	assert.Contains(t, s, "Section")
+	assert.NotContains(t, s, "bar.png")
+	assert.NotContains(t, s, "inside toggle")
+}
+
+func TestShowPageProperties(t *testing.T) {
+	collection := &testRecord{table: "collection", value: map[string]interface{}{
+		"id":    tid(10),
+		"alive": true,
+		"name":  title("Tasks"),
+		"schema": map[string]interface{}{
+			"title":  map[string]interface{}{"name": "Name", "type": "title"},
+			"status": map[string]interface{}{"name": "Status", "type": "select"},
+			"notes":  map[string]interface{}{"name": "Notes", "type": "text"},
+			"hidden": map[string]interface{}{"name": "Hidden", "type": "text"},
+		},
+		"format": map[string]interface{}{
+			"collection_page_properties": []interface{}{
+				map[string]interface{}{"property": "status", "visible": true},
+				map[string]interface{}{"property": "hidden", "visible": false},
+				map[string]interface{}{"property": "notes", "visible": true},
+			},
+		},
+	}}
+	page := newTestPage(t,
+		testBlock(tid(1), notionapi.BlockPage, "").title("Item").
+			set("parent_id", tid(10)).set("parent_table", "collection").
+			prop("status", title("Done")).prop("notes", title("a <note>")).prop("hidden", title("secret")),
+		collection,
+	)
+
+	c := NewConverter(page)
+	s := toHTML(t, c)
+	assert.NotContains(t, s, "notion-page-properties")
+
+	c = NewConverter(page)
+	c.ShowPageProperties = true
+	s = toHTML(t, c)
+	exp := `<dl class="notion-page-properties"><dt>Status</dt><dd>Done</dd><dt>Notes</dt><dd>a &lt;note&gt;</dd></dl></header>`
+	assert.Contains(t, s, exp)
+	assert.NotContains(t, s, "secret")
+}
+
+func TestRenderInlineCode(t *testing.T) {
+	code := []interface{}{[]interface{}{"x := 1", []interface{}{[]interface{}{"c"}}}}
+	page := newTestPage(t,
+		testBlock(tid(1), notionapi.BlockPage, "", tid(2)).title("Page"),
+		testBlock(tid(2), notionapi.BlockText, tid(1)).prop("title", code),
+	)
+	c := NewConverter(page)
+	s := toHTML(t, c)
+	assert.Contains(t, s, `<code class="notion-inline-code">x := 1</code>`)
+
+	c = NewConverter(page)
+	c.NotionCompat = true
+	c.PushNewBuffer()
+	c.RenderBlock(page.Root())
+	s = c.PopBuffer().String()
+	assert.Contains(t, s, `<code>x := 1</code>`)
+}
+
+func TestTwemojiCodepoints(t *testing.T) {
+	assert.Equal(t, "1f600", twemojiCodepoints("😀"))
+	assert.Equal(t, "1f44d-1f3fd", twemojiCodepoints("👍🏽"))
+	// variation selector is dropped
+	assert.Equal(t, "2764", twemojiCodepoints("❤️"))
+	// but not in ZWJ sequences
+	assert.Equal(t, "1f3f3-fe0f-200d-1f308", twemojiCodepoints("🏳️‍🌈"))
+}
+
+func TestTwemojiBaseURL(t *testing.T) {
+	page := newTestPage(t,
+		testBlock(tid(1), notionapi.BlockPage, "", tid(2)).title("Page").format("page_icon", "😀"),
+		testBlock(tid(2), notionapi.BlockCallout, tid(1)).title("note").format("page_icon", "👍🏽"),
+	)
+	c := NewConverter(page)
+	s := toHTML(t, c)
+	assert.Contains(t, s, `<span class="icon">😀</span>`)
+
+	c = NewConverter(page)
+	c.TwemojiBaseURL = "https://example.com/svg/"
+	s = toHTML(t, c)
+	assert.Contains(t, s, `<span class="icon"><img class="emoji" alt="😀" src="https://example.com/svg/1f600.svg"/></span>`)
+	assert.Contains(t, s, `<img class="emoji" alt="👍🏽" src="https://example.com/svg/1f44d-1f3fd.svg"/>`)
+}
+
+func TestLinkPagesRelatively(t *testing.T) {
+	mention := []interface{}{[]interface{}{"‣", []interface{}{[]interface{}{"p", tid(5)}}}}
+	page1 := newTestPage(t,
+		testBlock(tid(1), notionapi.BlockPage, "", tid(2)).title("First"),
+		testBlock(tid(2), notionapi.BlockText, tid(1)).prop("title", mention),
+	)
+	page2 := newTestPage(t,
+		testBlock(tid(5), notionapi.BlockPage, "").title("Second Page"),
+	)
+
+	c := NewConverter(page1)
+	c.Pages = []*notionapi.Page{page1, page2}
+	s := toHTML(t, c)
+	assert.Contains(t, s, `<a href="https://www.notion.so/`+notionapi.ToNoDashID(tid(5))+`">`)
+
+	c = NewConverter(page1)
+	c.Pages = []*notionapi.Page{page1, page2}
+	c.LinkPagesRelatively = true
+	s = toHTML(t, c)
+	assert.Contains(t, s, `<a href="Second Page.html">Second Page</a>`)
+}
+
+func TestRenderToggleableListItem(t *testing.T) {
+	page := newTestPage(t,
+		testBlock(tid(1), notionapi.BlockPage, "", tid(2), tid(4)).title("Page"),
+		testBlock(tid(2), notionapi.BlockBulletedList, tid(1), tid(3)).title("item").format("toggleable", true),
+		testBlock(tid(3), notionapi.BlockText, tid(2)).title("hidden"),
+		testBlock(tid(4), notionapi.BlockBulletedList, tid(1)).title("flat"),
+	)
+	c := NewConverter(page)
+	s := toHTML(t, c)
+	exp := fmt.Sprintf(`<ul id="%s" class="bulleted-list"><li><details><summary>item</summary><p id="%s">hidden</p></details></li></ul>`, tid(2), tid(3))
+	assert.Contains(t, s, exp)
+	exp = fmt.Sprintf(`<ul id="%s" class="bulleted-list"><li>flat</li></ul>`, tid(4))
+	assert.Contains(t, s, exp)
+}
+
+func TestRenderFilePreview(t *testing.T) {
+	imgSource := "https://s3-us-west-2.amazonaws.com/secure.notion-static.com/4c5e/chart.PNG"
+	pdfSource := "https://s3-us-west-2.amazonaws.com/secure.notion-static.com/4c5f/report.pdf"
+	page := newTestPage(t,
+		testBlock(tid(1), notionapi.BlockPage, "", tid(2), tid(3)).title("Page"),
+		testBlock(tid(2), notionapi.BlockFile, tid(1)).prop("source", title(imgSource)),
+		testBlock(tid(3), notionapi.BlockFile, tid(1)).prop("source", title(pdfSource)),
+	)
+	c := NewConverter(page)
+	s := toHTML(t, c)
+	exp := fmt.Sprintf(`<figure id="%s"><a href="Page/chart.PNG"><img class="notion-file-preview" src="Page/chart.PNG"/></a><div class="source"><a class="notion-file" href="Page/chart.PNG">`, tid(2))
+	assert.Contains(t, s, exp)
+	assert.NotContains(t, s, "<object")
+	assert.Contains(t, s, `<a class="notion-file" href="Page/report.pdf">`)
+
+	c = NewConverter(page)
+	c.EmbedPDFs = true
+	s = toHTML(t, c)
+	exp = fmt.Sprintf(`<figure id="%s"><object class="notion-file-preview" data="Page/report.pdf" type="application/pdf"></object><div class="source"><a class="notion-file" href="Page/report.pdf">`, tid(3))
+	assert.Contains(t, s, exp)
+}
+
+func TestUnresolvedRefs(t *testing.T) {
+	mention := func(id string) []interface{} {
+		return []interface{}{[]interface{}{"‣", []interface{}{[]interface{}{"p", id}}}}
+	}
+	page := newTestPage(t,
+		testBlock(tid(1), notionapi.BlockPage, "", tid(2), tid(3), tid(4), tid(5)).title("Page"),
+		testBlock(tid(2), notionapi.BlockText, tid(1)).prop("title", mention(tid(7))),
+		testBlock(tid(3), notionapi.BlockText, tid(1)).prop("title", mention(notionapi.ToNoDashID(tid(7)))),
+		testBlock(tid(4), notionapi.BlockText, tid(1)).prop("title", mention(tid(5))),
+		testBlock(tid(5), notionapi.BlockPage, tid(1)).title("Sub page"),
+	)
+	c := NewConverter(page)
+	toHTML(t, c)
+	assert.Equal(t, []string{tid(7)}, c.UnresolvedRefs)
+
+	other := newTestPage(t, testBlock(tid(7), notionapi.BlockPage, "").title("Other"))
+	c.Pages = []*notionapi.Page{page, other}
+	toHTML(t, c)
+	assert.Empty(t, c.UnresolvedRefs)
+}
+
+func TestRenderLineBreaks(t *testing.T) {
+	page := newTestPage(t,
+		testBlock(tid(1), notionapi.BlockPage, "", tid(2)).title("Page"),
+		testBlock(tid(2), notionapi.BlockText, tid(1)).title("first line\nsecond <line>"),
+	)
+	c := NewConverter(page)
+	s := toHTML(t, c)
+	assert.Contains(t, s, `>first line<br/>second &lt;line&gt;</p>`)
+
+	c = NewConverter(page)
+	c.PreserveLineBreaks = true
+	s = toHTML(t, c)
+	assert.Contains(t, s, ">first line\nsecond &lt;line&gt;</p>")
+}
+
+func TestCalloutAdmonitions(t *testing.T) {
+	tests := [][]string{
+		{"💡", "tip", "Tip"},
+		{"⚠️", "warning", "Warning"},
+		{"ℹ️", "note", "Note"},
+		{"🚨", "danger", "Danger"},
+	}
+	for _, test := range tests {
+		page := newTestPage(t,
+			testBlock(tid(1), notionapi.BlockPage, "", tid(2)).title("Page"),
+			testBlock(tid(2), notionapi.BlockCallout, tid(1)).title("text").format("page_icon", test[0]),
+		)
+		c := NewConverter(page)
+		s := toHTML(t, c)
+		assert.NotContains(t, s, "admonition")
+
+		c = NewConverter(page)
+		c.CalloutAdmonitions = true
+		s = toHTML(t, c)
+		exp := fmt.Sprintf(`<div id="%s" class="admonition admonition-%s"><p class="admonition-title">%s</p><div class="admonition-content">text</div></div>`, tid(2), test[1], test[2])
+		assert.Contains(t, s, exp)
+	}
+
+	// callouts with other icons are not changed
+	page := newTestPage(t,
+		testBlock(tid(1), notionapi.BlockPage, "", tid(2)).title("Page"),
+		testBlock(tid(2), notionapi.BlockCallout, tid(1)).title("text").format("page_icon", "🐱"),
+	)
+	c := NewConverter(page)
+	c.CalloutAdmonitions = true
+	s := toHTML(t, c)
+	assert.NotContains(t, s, "admonition")
+	assert.Contains(t, s, `class="callout"`)
+}
+
+func TestRenderSyncedBlock(t *testing.T) {
+	page := newTestPage(t,
+		testBlock(tid(1), notionapi.BlockPage, "", tid(2), tid(4)).title("Page"),
+		testBlock(tid(2), notionapi.BlockTransclusionContainer, tid(1), tid(3)),
+		testBlock(tid(3), notionapi.BlockText, tid(2)).title("synced text"),
+		testBlock(tid(4), notionapi.BlockTransclusionReference, tid(1)).
+			format("transclusion_reference_pointer", map[string]interface{}{"id": tid(2), "table": "block"}),
+	)
+	c := NewConverter(page)
+	s := toHTML(t, c)
+	exp := fmt.Sprintf(`<div id="%s" class="notion-synced-block"><p id="%s">synced text</p></div>`, tid(4), tid(3))
+	assert.Contains(t, s, exp)
+	assert.NotContains(t, s, "notion-synced-from")
+
+	c = NewConverter(page)
+	c.ShowSyncedFrom = true
+	s = toHTML(t, c)
+	exp = fmt.Sprintf(`<div id="%s" class="notion-synced-block"><div class="notion-synced-from">Synced from <a href="Page.html">Page</a></div><p id="%s">synced text</p></div>`, tid(4), tid(3))
+	assert.Contains(t, s, exp)
+}
+
+func TestBlockTimer(t *testing.T) {
+	page := newTestPage(t,
+		testBlock(tid(1), notionapi.BlockPage, "", tid(2), tid(3)).title("Page"),
+		testBlock(tid(2), notionapi.BlockText, tid(1)).title("text"),
+		testBlock(tid(3), notionapi.BlockToggle, tid(1), tid(4)).title("toggle"),
+		testBlock(tid(4), notionapi.BlockText, tid(3)).title("inside"),
+	)
+	c := NewConverter(page)
+	var types []string
+	c.BlockTimer = func(blockType string, d time.Duration) {
+		assert.True(t, d >= 0)
+		types = append(types, blockType)
+	}
+	toHTML(t, c)
+	// blocks are reported after their children
+	exp := []string{notionapi.BlockText, notionapi.BlockText, notionapi.BlockToggle, notionapi.BlockPage}
+	assert.Equal(t, exp, types)
+}
+
+func TestRenderCollectionGroupBy(t *testing.T) {
+	schema := map[string]interface{}{
+		"title":  map[string]interface{}{"name": "Name", "type": "title"},
+		"status": map[string]interface{}{"name": "Status", "type": "select"},
+	}
+	groups := `"collection_groups": [
+		{"property": "status", "value": {"type": "select", "value": "Todo"}},
+		{"property": "status", "value": {"type": "select", "value": "Done"}},
+		{"property": "status", "value": {"type": "select", "value": "Archived"}, "hidden": true}
+	]`
+	tableProps := `"table_properties": [
+		{"property": "title", "visible": true},
+		{"property": "status", "visible": true}
+	]`
+	// grouping is either in format.collection_group_by or in query2.group_by
+	views := []string{
+		`{"type": "table", "format": {` + tableProps + `, ` + groups + `,
+			"collection_group_by": {"type": "select", "property": "status"}}}`,
+		`{"type": "table", "format": {` + tableProps + `, ` + groups + `},
+			"query2": {"group_by": "status"}}`,
+	}
+	for _, js := range views {
+		var view map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(js), &view))
+		page := newTestCollectionPageWithView(t, schema, view,
+			testBlock(tid(3), notionapi.BlockPage, "").title("Task A").prop("status", title("Done")),
+			testBlock(tid(4), notionapi.BlockPage, "").title("Task B").prop("status", title("Todo")),
+			testBlock(tid(5), notionapi.BlockPage, "").title("Task C").prop("status", title("Todo")),
+			testBlock(tid(6), notionapi.BlockPage, "").title("Task D"),
+			testBlock(tid(7), notionapi.BlockPage, "").title("Task E").prop("status", title("Archived")),
+		)
+
+		s := toHTML(t, NewConverter(page))
+		todo := strings.Index(s, `<th colspan="2">Todo <span class="notion-collection-group-count">2</span></th>`)
+		done := strings.Index(s, `<th colspan="2">Done <span class="notion-collection-group-count">1</span></th>`)
+		none := strings.Index(s, `<th colspan="2">No Status <span class="notion-collection-group-count">1</span></th>`)
+		require.True(t, todo >= 0 && done >= 0 && none >= 0, s)
+		assert.True(t, todo < done && done < none)
+		assert.True(t, todo < strings.Index(s, "Task B") && strings.Index(s, "Task C") < done)
+		assert.Equal(t, 3, strings.Count(s, `<tbody class="notion-collection-group">`))
+		assert.NotContains(t, s, "Task E")
+	}
+}
+
+func TestBlockRenderers(t *testing.T) {
+	page := newTestPage(t,
+		testBlock(tid(1), notionapi.BlockPage, "", tid(2), tid(3)).title("Page"),
+		testBlock(tid(2), notionapi.BlockDivider, tid(1)),
+		testBlock(tid(3), notionapi.BlockText, tid(1)).title("after divider"),
+	)
+	c := NewConverter(page)
+	c.BlockRenderers = map[string]func(*notionapi.Block){
+		notionapi.BlockDivider: func(block *notionapi.Block) {
+			c.Printf(`<div class="custom-divider" id="%s"></div>`, block.ID)
+		},
+	}
+	s := toHTML(t, c)
+	assert.Contains(t, s, `<div class="custom-divider" id="`+tid(2)+`"></div>`)
+	assert.NotContains(t, s, "<hr")
+	assert.Contains(t, s, "after divider")
+}
+
+func TestRootTag(t *testing.T) {
+	page := newTestPage(t,
+		testBlock(tid(1), notionapi.BlockPage, "").title("Page"),
+	)
+	c := NewConverter(page)
+	c.RootTag = "main"
+	s := toHTML(t, c)
+	assert.Contains(t, s, `<main id="`+tid(1)+`" class="page sans">`)
+	assert.True(t, strings.HasSuffix(strings.TrimSpace(s), "</main>"), s)
+	assert.NotContains(t, s, "<article")
+
+	c = NewConverter(page)
+	c.RootTag = "span"
+	_, err := c.ToHTML()
+	assert.Error(t, err)
+}
+
+func TestPageLayoutFlags(t *testing.T) {
+	page := newTestPage(t,
+		testBlock(tid(1), notionapi.BlockPage, "").title("Page").format("page_small_text", true),
+	)
+	s := toHTML(t, NewConverter(page))
+	assert.Contains(t, s, `<article id="`+tid(1)+`" class="page sans page-small-text">`)
+	assert.NotContains(t, s, `class="page sans page-small-text page-full-width"`)
+
+	page = newTestPage(t,
+		testBlock(tid(1), notionapi.BlockPage, "").title("Page").
+			format("page_full_width", true).format("page_font", "serif"),
+	)
+	s = toHTML(t, NewConverter(page))
+	assert.Contains(t, s, `<article id="`+tid(1)+`" class="page serif page-full-width">`)
+}
+
+func TestRenderBlockToHTML(t *testing.T) {
+	page := newTestPage(t,
+		testBlock(tid(1), notionapi.BlockPage, "", tid(2), tid(3), tid(5)).title("Page"),
+		testBlock(tid(2), notionapi.BlockText, tid(1)).title("sibling before"),
+		testBlock(tid(3), notionapi.BlockToggle, tid(1), tid(4)).title("my toggle"),
+		testBlock(tid(4), notionapi.BlockText, tid(3)).title("inside toggle"),
+		testBlock(tid(5), notionapi.BlockBulletedList, tid(1)).title("list item"),
+	)
+	c := NewConverter(page)
+	d, err := c.RenderBlockToHTML(page.BlockByID(tid(3)))
+	require.NoError(t, err)
+	s := string(d)
+	assert.True(t, strings.HasPrefix(s, `<ul id="`+tid(3)+`" class="toggle">`), s)
+	assert.Contains(t, s, "<summary>my toggle</summary>")
+	assert.Contains(t, s, "inside toggle")
+	assert.NotContains(t, s, "sibling before")
+	assert.NotContains(t, s, "<article")
+
+	d, err = c.RenderBlockToHTML(page.BlockByID(tid(5)))
+	require.NoError(t, err)
+	s = string(d)
+	assert.True(t, strings.HasPrefix(s, "<ul"), s)
+	assert.True(t, strings.HasSuffix(strings.TrimSpace(s), "</ul>"), s)
+}
+
+func TestRenderLinkToPageVsMentionPage(t *testing.T) {
+	other := newTestPage(t,
+		testBlock(tid(10), notionapi.BlockPage, tid(100)).title("Other").format("page_icon", "🚀"),
+	)
+	page := newTestPage(t,
+		testBlock(tid(1), notionapi.BlockPage, tid(100), tid(2), tid(3), tid(4)).title("Root"),
+		testBlock(tid(2), notionapi.BlockAlias, tid(1)).format("alias_pointer", map[string]interface{}{"id": tid(10), "table": "block"}),
+		testBlock(tid(3), notionapi.BlockPage, tid(5)).title("Mentioned"),
+		testBlock(tid(4), notionapi.BlockAlias, tid(1)).format("alias_pointer", map[string]interface{}{"id": tid(11), "table": "block"}),
+	)
+	c := NewConverter(page)
+	c.Pages = []*notionapi.Page{page, other}
+	c.LinkPagesRelatively = true
+	s := toHTML(t, c)
+	// "link to page" block links to its target
+	assert.Contains(t, s, fmt.Sprintf(`<figure id="%s" class="link-to-page"><a href="Other.html"><span class="icon">🚀</span>Other</a></figure>`, tid(2)))
+	assert.Contains(t, s, fmt.Sprintf(`<figure id="%s" class="link-to-page"><a href="https://www.notion.so/Untitled-%s">Untitled</a></figure>`, tid(4), notionapi.ToNoDashID(tid(11))))
+	assert.Equal(t, []string{tid(11)}, c.UnresolvedRefs)
+	// a page block that lives in another page is a mention
+	assert.Contains(t, s, fmt.Sprintf(`<figure id="%s" class="mention-page"><a href="https://www.notion.so/Mentioned-%s">Mentioned</a></figure>`, tid(3), notionapi.ToNoDashID(tid(3))))
+
+	c = NewConverter(page)
+	c.NotionCompat = true
+	c.PushNewBuffer()
+	c.RenderBlock(page.Root())
+	s = c.PopBuffer().String()
+	assert.Contains(t, s, fmt.Sprintf(`<figure id="%s" class="link-to-page">`, tid(3)))
+	assert.NotContains(t, s, "mention-page")
+}
+
+func TestRenderCollectionViewMissingCollection(t *testing.T) {
+	schema := map[string]interface{}{
+		"title": map[string]interface{}{"name": "Name", "type": "title"},
+	}
+	tableProps := []interface{}{
+		map[string]interface{}{"property": "title", "visible": true},
+	}
+	page := newTestCollectionPage(t, schema, tableProps,
+		testBlock(tid(3), notionapi.BlockPage, "").title("Row"),
+	)
+	page.BlockByID(tid(2)).CollectionViews[0].Collection = nil
+	s := toHTML(t, NewConverter(page))
+	assert.Contains(t, s, fmt.Sprintf(`<div id="%s" class="collection-content missing"></div>`, tid(2)))
+	assert.NotContains(t, s, "<table")
+}
+
+func TestAccessibleCaptions(t *testing.T) {
+	page := newTestPage(t,
+		testBlock(tid(1), notionapi.BlockPage, "", tid(2), tid(3)).title("Page"),
+		testBlock(tid(2), notionapi.BlockImage, tid(1)).
+			prop("source", title("https://example.com/a.png")).prop("caption", title("A cat")),
+		testBlock(tid(3), notionapi.BlockImage, tid(1)).
+			prop("source", title("https://example.com/b.png")),
+	)
+	c := NewConverter(page)
+	c.AccessibleCaptions = true
+	s := toHTML(t, c)
+	assert.Contains(t, s, fmt.Sprintf(`<figure id="%s" class="image" aria-describedby="caption-%s">`, tid(2), tid(2)))
+	assert.Contains(t, s, fmt.Sprintf(`<figcaption id="caption-%s">A cat</figcaption>`, tid(2)))
+	// no caption, nothing to reference
+	assert.Contains(t, s, fmt.Sprintf(`<figure id="%s" class="image">`, tid(3)))
+	assert.Equal(t, 1, strings.Count(s, "aria-describedby"))
+
+	s = toHTML(t, NewConverter(page))
+	assert.NotContains(t, s, "aria-describedby")
+	assert.Contains(t, s, `<figcaption>A cat</figcaption>`)
+}
+
+func TestRenderDatabaseMention(t *testing.T) {
+	mention := []interface{}{[]interface{}{"‣", []interface{}{[]interface{}{"p", tid(3)}}}}
+	page := newTestPage(t,
+		testBlock(tid(1), notionapi.BlockPage, "", tid(2), tid(3)).title("Page"),
+		testBlock(tid(2), notionapi.BlockText, tid(1)).prop("title", mention),
+		testBlock(tid(3), notionapi.BlockCollectionViewPage, tid(1)).set("collection_id", tid(10)),
+		&testRecord{table: "collection", value: map[string]interface{}{
+			"id":    tid(10),
+			"alive": true,
+			"name":  title("Reading List"),
+			"icon":  "📚",
+		}},
+	)
+	s := toHTML(t, NewConverter(page))
+	uri := "https://www.notion.so/Reading-List-" + notionapi.ToNoDashID(tid(3))
+	assert.Contains(t, s, `<a href="`+uri+`"><span class="icon notion-page-mention-icon">📚</span>Reading List</a>`)
+}
+
+func TestExportPageZip(t *testing.T) {
+	imageURL := "https://s3-us-west-2.amazonaws.com/secure.notion-static.com/abc/cat.png"
+	page := newTestPage(t,
+		testBlock(tid(1), notionapi.BlockPage, "", tid(2), tid(3)).title("My Page"),
+		testBlock(tid(2), notionapi.BlockImage, tid(1)).
+			prop("source", title(imageURL)).set("file_ids", []string{"abc"}),
+		testBlock(tid(3), notionapi.BlockCollectionViewPage, tid(1)).set("collection_id", tid(10)),
+		&testRecord{table: "collection", value: map[string]interface{}{
+			"id":    tid(10),
+			"alive": true,
+			"name":  title("Tasks"),
+			"icon":  "https://example.com/db.png",
+		}},
+	)
+	imageData := []byte("fake png data")
+	iconData := []byte("fake icon data")
+	transport := func(req *http.Request) (*http.Response, error) {
+		var body []byte
+		switch req.URL.Path {
+		case "/api/v3/getSignedFileUrls":
+			body, _ = json.Marshal(map[string]interface{}{
+				"signedUrls": []string{"https://files.example.com/signed/cat.png"},
+			})
+		case "/signed/cat.png":
+			body = imageData
+		case "/db.png":
+			body = iconData
+		default:
+			return nil, fmt.Errorf("unexpected request %s", req.URL)
+		}
+		return &http.Response{
+			StatusCode: 200,
+			Body:       ioutil.NopCloser(bytes.NewReader(body)),
+			Header:     http.Header{},
+		}, nil
+	}
+	client := &notionapi.Client{
+		HTTPClient: &http.Client{Transport: roundTripFunc(transport)},
+	}
+
+	var buf bytes.Buffer
+	err := ExportPageZip(client, page, &buf)
+	require.NoError(t, err)
+
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	require.NoError(t, err)
+	files := map[string][]byte{}
+	for _, f := range zr.File {
+		r, err := f.Open()
+		require.NoError(t, err)
+		d, err := ioutil.ReadAll(r)
+		require.NoError(t, err)
+		r.Close()
+		files[f.Name] = d
+	}
+	require.Len(t, files, 3)
+	html := string(files["My Page.html"])
+	assert.Contains(t, html, `<img src="My Page/cat.png"/>`)
+	assert.Contains(t, html, `<img class="icon" src="My Page/Tasks/db.png"/>`)
+	assert.Contains(t, html, "<html>")
+	assert.Equal(t, imageData, files["My Page/cat.png"])
+	assert.Equal(t, iconData, files["My Page/Tasks/db.png"])
+}
+
+func TestRenderExternalObjectMention(t *testing.T) {
+	spans := []interface{}{
+		[]interface{}{"‣", []interface{}{[]interface{}{"eoi", "00000000-0000-0000-0000-0000000000e1"}}},
+		[]interface{}{" and "},
+		[]interface{}{"PR #12", []interface{}{[]interface{}{"eoi", "00000000-0000-0000-0000-0000000000e2"}}},
+	}
+	page := newTestPage(t,
+		testBlock(tid(1), notionapi.BlockPage, "", tid(2)).title("Page"),
+		testBlock(tid(2), notionapi.BlockText, tid(1)).prop("title", spans),
+	)
+	s := toHTML(t, NewConverter(page))
+	assert.Contains(t, s, fmt.Sprintf(`<p id="%s"> and <span class="notion-external-object">PR #12</span></p>`, tid(2)))
+	assert.NotContains(t, s, "‣")
+}
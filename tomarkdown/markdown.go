@@ -3,7 +3,10 @@ package tomarkdown
 import (
 	"bytes"
 	"fmt"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/kjk/notionapi"
 )
@@ -41,6 +44,11 @@ type Converter struct {
 	// to destination URLs
 	RewriteURL func(url string) string
 
+	// if set, markdown starts with YAML front matter (used by static site
+	// generators like Hugo or Jekyll) with values returned by the function.
+	// Use DefaultFrontMatter for page title and last edited date
+	FrontMatterFunc func(page *notionapi.Page) map[string]string
+
 	// data provided by they caller, useful when providing
 	// RenderBlockOverride
 	Data interface{}
@@ -237,9 +245,67 @@ func (c *Converter) bufferEndsWith(s string) bool {
 }
 */
 
+func isAlnum(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
+
+func isSpaceAt(s string, i int) bool {
+	return i >= 0 && i < len(s) && (s[i] == ' ' || s[i] == '\t' || s[i] == '\n')
+}
+
+// escapeMarkdown backslash-escapes characters in text that would otherwise
+// be interpreted as markdown formatting (\, *, _, #, ` and [). Characters
+// that can't start formatting are left alone e.g. "2 * 3", "snake_case"
+// or "#hashtag" in the middle of a line. Edges of s are treated as
+// if they were next to text because text is rendered in spans
+func escapeMarkdown(s string) string {
+	var res []byte
+	atLineStart := true
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		escape := false
+		switch c {
+		case '\\', '`':
+			// \ must be escaped or it would escape the character after it
+			escape = true
+		case '[':
+			escape = strings.IndexByte(s[i+1:], ']') >= 0
+		case '#':
+			escape = atLineStart
+		case '*', '_':
+			// surrounded by spaces, it's not emphasis
+			escape = !(isSpaceAt(s, i-1) && isSpaceAt(s, i+1))
+			// intra-word _ is not emphasis in CommonMark
+			if c == '_' && i > 0 && i+1 < len(s) && isAlnum(s[i-1]) && isAlnum(s[i+1]) {
+				escape = false
+			}
+		}
+		if escape {
+			res = append(res, '\\')
+		}
+		res = append(res, c)
+		if c == '\n' {
+			atLineStart = true
+		} else if c != ' ' && c != '\t' {
+			atLineStart = false
+		}
+	}
+	return string(res)
+}
+
 // InlineToString renders inline block
 func (c *Converter) InlineToString(b *notionapi.TextSpan) string {
 	text := b.Text
+	isCode := false
+	for _, attr := range b.Attrs {
+		if notionapi.AttrGetType(attr) == notionapi.AttrCode {
+			isCode = true
+		}
+	}
+	// text of code is literal
+	if !isCode {
+		text = escapeMarkdown(text)
+	}
 	var start, end, before, after string
 	for _, attr := range b.Attrs {
 		switch notionapi.AttrGetType(attr) {
@@ -473,7 +539,7 @@ func (c *Converter) RenderDivider(block *notionapi.Block) {
 
 // RenderBookmark renders BlockBookmark
 func (c *Converter) RenderBookmark(block *notionapi.Block) {
-	title := notionapi.TextSpansToString(block.InlineContent)
+	title := block.PlainText()
 	uri := block.Link
 	if title == "" && uri == "" {
 		return
@@ -605,14 +671,90 @@ func (c *Converter) RenderColumn(block *notionapi.Block) {
 	c.RenderChildren(block)
 }
 
-// RenderCollectionView renders BlockCollectionView
+// tableCell returns content of a cell of GFM table. Cells can't span
+// lines so newlines are replaced with <br>
+func (c *Converter) tableCell(spans []*notionapi.TextSpan) string {
+	s := c.GetInlineContent(spans, true)
+	s = strings.TrimSpace(s)
+	s = strings.Replace(s, "|", `\|`, -1)
+	s = strings.Replace(s, "\n", "<br>", -1)
+	return s
+}
+
+// renderGFMTable renders GitHub-flavored markdown table. First row is
+// the header row because GFM tables must have one
+func (c *Converter) renderGFMTable(rows [][]string) {
+	if len(rows) == 0 || len(rows[0]) == 0 {
+		return
+	}
+	c.Newline()
+	writeRow := func(cells []string) {
+		c.WriteString("| " + strings.Join(cells, " | ") + " |\n")
+	}
+	writeRow(rows[0])
+	sep := make([]string, len(rows[0]))
+	for i := range sep {
+		sep[i] = "---"
+	}
+	writeRow(sep)
+	for _, row := range rows[1:] {
+		writeRow(row)
+	}
+	c.Newline()
+}
+
+// RenderCollectionView renders BlockCollectionView as GFM table with
+// visible columns of the first view
 func (c *Converter) RenderCollectionView(block *notionapi.Block) {
 	if len(block.CollectionViews) == 0 {
 		return
 	}
-	c.WriteString("RendeRenderCollectionViewrCode NYI\n")
+	viewInfo := block.CollectionViews[0]
+	view := viewInfo.CollectionView
+	collection := viewInfo.Collection
+	if view == nil || view.Format == nil || collection == nil {
+		return
+	}
+	var columns []string
+	var header []string
+	for _, prop := range view.Format.TableProperties {
+		// title column can't be hidden in Notion
+		if !prop.Visible && prop.Property != "title" {
+			continue
+		}
+		name := ""
+		if info := collection.CollectionSchema[prop.Property]; info != nil {
+			name = info.Name
+		}
+		columns = append(columns, prop.Property)
+		header = append(header, c.tableCell([]*notionapi.TextSpan{{Text: name}}))
+	}
+	rows := [][]string{header}
+	for _, row := range viewInfo.CollectionRows {
+		var cells []string
+		for _, colID := range columns {
+			cells = append(cells, c.tableCell(row.GetProperty(colID)))
+		}
+		rows = append(rows, cells)
+	}
+	c.renderGFMTable(rows)
+}
 
-	c.RenderChildren(block)
+// RenderTable renders BlockTable as GFM table
+func (c *Converter) RenderTable(block *notionapi.Block) {
+	f := block.FormatTable()
+	if f == nil {
+		return
+	}
+	var rows [][]string
+	for _, row := range block.Content {
+		var cells []string
+		for _, colID := range f.ColumnOrder {
+			cells = append(cells, c.tableCell(row.GetProperty(colID)))
+		}
+		rows = append(rows, cells)
+	}
+	c.renderGFMTable(rows)
 }
 
 // DefaultRenderFunc returns a defult rendering function for a type of
@@ -653,6 +795,8 @@ func (c *Converter) DefaultRenderFunc(blockType string) func(*notionapi.Block) {
 		return c.RenderColumn
 	case notionapi.BlockCollectionView:
 		return c.RenderCollectionView
+	case notionapi.BlockTable:
+		return c.RenderTable
 	case notionapi.BlockEmbed:
 		return c.RenderEmbed
 	case notionapi.BlockMaps:
@@ -765,9 +909,41 @@ func (c *Converter) ToMarkdown() []byte {
 	// which adds empty lines at top and bottom
 	d := buf.Bytes()
 	d = bytes.TrimSpace(d)
+	if c.FrontMatterFunc != nil {
+		fm := FormatFrontMatter(c.FrontMatterFunc(c.Page))
+		d = append([]byte(fm), d...)
+	}
 	return d
 }
 
+// DefaultFrontMatter returns title and last edited date (as "date")
+// of the page, for use as FrontMatterFunc
+func DefaultFrontMatter(page *notionapi.Page) map[string]string {
+	root := page.Root()
+	res := map[string]string{
+		"title": page.Title(),
+	}
+	if root != nil && root.LastEditedTime > 0 {
+		res["date"] = root.UpdatedOn().UTC().Format(time.RFC3339)
+	}
+	return res
+}
+
+// FormatFrontMatter returns YAML front matter (delimited with "---" lines)
+// with values as quoted strings. Keys are sorted
+func FormatFrontMatter(m map[string]string) string {
+	var keys []string
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	s := "---\n"
+	for _, k := range keys {
+		s += fmt.Sprintf("%s: %s\n", k, strconv.Quote(m[k]))
+	}
+	return s + "---\n\n"
+}
+
 // ToMarkdown converts a page to Markdown
 func ToMarkdown(page *notionapi.Page) []byte {
 	r := NewConverter(page)
@@ -1,9 +1,16 @@
 package tomarkdown
 
 import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strings"
 	"testing"
 
+	"github.com/kjk/notionapi"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestMarkdownFileNameForPage(t *testing.T) {
@@ -21,3 +28,169 @@ func TestMarkdownFileNameForPage(t *testing.T) {
 		assert.Equal(t, test[2], got)
 	}
 }
+
+func TestEscapeMarkdown(t *testing.T) {
+	tests := [][]string{
+		{"plain text", "plain text"},
+		{"use *args", `use \*args`},
+		{"2 * 3", "2 * 3"},
+		{"**not bold**", `\*\*not bold\*\*`},
+		{"_italic_", `\_italic\_`},
+		{"snake_case_name", "snake_case_name"},
+		{"call `foo`", "call \\`foo\\`"},
+		{"# not a header", `\# not a header`},
+		{"issue #3", "issue #3"},
+		{"[x] done", `\[x] done`},
+		{"a [ b", "a [ b"},
+		{`C:\dir\file`, `C:\\dir\\file`},
+		{`\*not escaped*`, `\\\*not escaped\*`},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test[1], escapeMarkdown(test[0]), "input: %s", test[0])
+	}
+}
+
+func TestInlineToStringEscapes(t *testing.T) {
+	c := NewConverter(nil)
+	s := c.InlineToString(&notionapi.TextSpan{Text: "use *args"})
+	assert.Equal(t, `use \*args`, s)
+	code := &notionapi.TextSpan{
+		Text:  "*args",
+		Attrs: []notionapi.TextAttr{{notionapi.AttrCode}},
+	}
+	assert.Equal(t, "`*args`", c.InlineToString(code))
+}
+
+func TestRenderCollectionViewGFMTable(t *testing.T) {
+	row := func(name, notes string) *notionapi.Block {
+		return &notionapi.Block{
+			Type: notionapi.BlockPage,
+			Properties: map[string]interface{}{
+				"title": []interface{}{[]interface{}{name}},
+				"notes": []interface{}{[]interface{}{notes}},
+			},
+		}
+	}
+	block := &notionapi.Block{
+		Type: notionapi.BlockCollectionView,
+		CollectionViews: []*notionapi.CollectionViewInfo{
+			{
+				CollectionView: &notionapi.CollectionView{
+					Format: &notionapi.CollectionViewFormat{
+						TableProperties: []*notionapi.TableProperty{
+							{Property: "title", Visible: true},
+							{Property: "hidden", Visible: false},
+							{Property: "notes", Visible: true},
+						},
+					},
+				},
+				Collection: &notionapi.Collection{
+					CollectionSchema: map[string]*notionapi.CollectionColumnInfo{
+						"title":  {Name: "Name", Type: "title"},
+						"notes":  {Name: "Notes", Type: "text"},
+						"hidden": {Name: "Hidden", Type: "text"},
+					},
+				},
+				CollectionRows: []*notionapi.Block{
+					row("First", "a | b"),
+					row("Second", "line 1\nline 2"),
+				},
+			},
+		},
+	}
+	c := NewConverter(nil)
+	c.PushNewBuffer()
+	c.RenderCollectionView(block)
+	s := c.PopBuffer().String()
+	exp := `| Name | Notes |
+| --- | --- |
+| First | a \| b |
+| Second | line 1<br>line 2 |`
+	assert.Equal(t, exp, strings.TrimSpace(s))
+}
+
+func TestRenderTable(t *testing.T) {
+	var blocks []map[string]interface{}
+	for _, s := range []string{
+		`{"id": "00000000-0000-0000-0000-000000000001", "type": "page", "alive": true,
+		"properties": {"title": [["Page"]]},
+		"content": ["00000000-0000-0000-0000-000000000002"]}`,
+		`{"id": "00000000-0000-0000-0000-000000000002", "type": "table", "alive": true,
+		"parent_id": "00000000-0000-0000-0000-000000000001", "parent_table": "block",
+		"format": {"table_block_column_order": ["a", "b"], "table_block_column_header": true},
+		"content": ["00000000-0000-0000-0000-000000000003", "00000000-0000-0000-0000-000000000004"]}`,
+		`{"id": "00000000-0000-0000-0000-000000000003", "type": "table_row", "alive": true,
+		"parent_id": "00000000-0000-0000-0000-000000000002", "parent_table": "block",
+		"properties": {"a": [["Command"]], "b": [["Description"]]}}`,
+		`{"id": "00000000-0000-0000-0000-000000000004", "type": "table_row", "alive": true,
+		"parent_id": "00000000-0000-0000-0000-000000000002", "parent_table": "block",
+		"properties": {"a": [["ls | wc", [["c"]]]], "b": [["count files"]]}}`,
+	} {
+		var m map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(s), &m))
+		blocks = append(blocks, m)
+	}
+	page := newTestPage(t, blocks[0], blocks[1:]...)
+	s := string(NewConverter(page).ToMarkdown())
+	exp := "| Command | Description |\n| --- | --- |\n| `ls \\| wc` | count files |\n"
+	assert.Contains(t, s, exp)
+}
+
+func TestFrontMatter(t *testing.T) {
+	page := newTestPage(t, map[string]interface{}{
+		"id":               "00000000-0000-0000-0000-000000000001",
+		"type":             notionapi.BlockPage,
+		"alive":            true,
+		"last_edited_time": 1600000000000,
+		"properties":       map[string]interface{}{"title": []interface{}{[]interface{}{`My "Post"`}}},
+	})
+	c := NewConverter(page)
+	s := string(c.ToMarkdown())
+	assert.False(t, strings.HasPrefix(s, "---"))
+
+	c = NewConverter(page)
+	c.FrontMatterFunc = DefaultFrontMatter
+	s = string(c.ToMarkdown())
+	exp := "---\ndate: \"2020-09-13T12:26:40Z\"\ntitle: \"My \\\"Post\\\"\"\n---\n\n# My \"Post\"\n"
+	assert.Equal(t, exp, s[:len(exp)])
+}
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
+
+// newTestPage builds a Page from a root block and its descendants by
+// faking responses of Notion API
+func newTestPage(t *testing.T, root map[string]interface{}, blocks ...map[string]interface{}) *notionapi.Page {
+	withRole := map[string]interface{}{"role": "reader", "value": root}
+	blockMap := map[string]interface{}{root["id"].(string): withRole}
+	for _, b := range blocks {
+		blockMap[b["id"].(string)] = map[string]interface{}{"role": "reader", "value": b}
+	}
+	transport := func(req *http.Request) (*http.Response, error) {
+		var rsp interface{} = map[string]interface{}{
+			"cursor":    map[string]interface{}{"stack": []interface{}{}},
+			"recordMap": map[string]interface{}{"block": blockMap},
+		}
+		if req.URL.Path == "/api/v3/getRecordValues" {
+			rsp = map[string]interface{}{"results": []interface{}{withRole}}
+		}
+		d, err := json.Marshal(rsp)
+		if err != nil {
+			return nil, err
+		}
+		return &http.Response{
+			StatusCode: 200,
+			Body:       ioutil.NopCloser(bytes.NewReader(d)),
+			Header:     http.Header{},
+		}, nil
+	}
+	client := &notionapi.Client{
+		HTTPClient: &http.Client{Transport: roundTripFunc(transport)},
+	}
+	page, err := client.DownloadPage(root["id"].(string))
+	require.NoError(t, err)
+	return page
+}